@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// BatchCall is a call sent within a batch by Client.CallBatch, either Result or Err is set once it is answered
+type BatchCall struct {
+	Method protocol.Method
+	Params protocol.ClientRequest
+
+	Result json.RawMessage
+	Err    error
+}
+
+func NewBatchCall(method protocol.Method, params protocol.ClientRequest) *BatchCall {
+	return &BatchCall{Method: method, Params: params}
+}
+
+// CallBatch sends calls as one JSON-RPC batch and waits for all their responses.
+// The outcome of each call is set in it, the returned error reports that the batch could not be sent or completed.
+func (client *Client) CallBatch(ctx context.Context, calls ...*BatchCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	if !client.ready.Load() {
+		return fmt.Errorf("client not ready")
+	}
+	if version := client.GetProtocolVersion(); !protocol.IsBatchSupported(version) {
+		return fmt.Errorf("batch not supported by protocol version %q", version)
+	}
+
+	requestIDs := make([]string, len(calls))
+	respChans := make([]chan *protocol.JSONRPCResponse, len(calls))
+	requests := make([]*protocol.JSONRPCRequest, len(calls))
+	defer func() {
+		for _, requestID := range requestIDs {
+			if requestID != "" {
+				client.reqID2respChan.Remove(protocol.RequestIDKey(requestID))
+			}
+		}
+	}()
+	for i, call := range calls {
+		if call.Method == protocol.Initialize {
+			return errors.New("initialize request can't be sent in a batch")
+		}
+
+		requestIDs[i] = strconv.FormatInt(atomic.AddInt64(&client.requestID, 1), 10)
+		respChans[i] = make(chan *protocol.JSONRPCResponse, 1)
+		client.reqID2respChan.Set(protocol.RequestIDKey(requestIDs[i]), respChans[i])
+
+		requests[i] = protocol.NewJSONRPCRequest(requestIDs[i], call.Method, call.Params)
+	}
+
+	message, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+
+	connLost := client.getConnLost()
+
+	if err = client.transport.Send(ctx, message); err != nil {
+		return fmt.Errorf("callBatch: transport send: %w", err)
+	}
+
+	for i, call := range calls {
+		select {
+		case <-ctx.Done():
+			for j := i; j < len(calls); j++ {
+				client.cancelRequest(requestIDs[j], ctx.Err().Error())
+				calls[j].Err = ctx.Err()
+			}
+			return ctx.Err()
+		case <-connLost.done:
+			err = fmt.Errorf("callBatch: %w", connLost.err)
+			for j := i; j < len(calls); j++ {
+				calls[j].Err = err
+			}
+			return err
+		case response := <-respChans[i]:
+			if respErr := response.Error; respErr != nil {
+				call.Err = pkg.NewResponseError(respErr.Code, respErr.Message, respErr.Data)
+			} else {
+				call.Result = response.RawResult
+			}
+		}
+	}
+	return nil
+}
+
+// receiveBatch dispatches the members of a batch concurrently, the responses to its requests are sent back as one array
+func (client *Client) receiveBatch(msg []byte) error {
+	if version := client.GetProtocolVersion(); !protocol.IsBatchSupported(version) {
+		return client.rejectBatch(fmt.Errorf("%w: batch not supported by protocol version %q", pkg.ErrRequestInvalid, version))
+	}
+
+	members := gjson.ParseBytes(msg).Array()
+	if len(members) == 0 {
+		return client.rejectBatch(fmt.Errorf("%w: empty batch", pkg.ErrRequestInvalid))
+	}
+
+	batch := &pkg.BatchResponses{}
+	for _, member := range members {
+		// a member that is neither a request, a notification nor a response can't be identified, its error has a null id
+		if !member.IsObject() || (!member.Get("method").Exists() && !member.Get("id").Exists()) {
+			resp, _ := json.Marshal(protocol.NewJSONRPCErrorResponse(nil, protocol.InvalidRequest, "invalid batch member"))
+			batch.Add(resp)
+			continue
+		}
+
+		if err := client.receiveMessage([]byte(member.Raw), batch); err != nil {
+			client.logger.Errorf("receive batch member:%s error: %s", member.Get("method").String(), err.Error())
+
+			// the requests that can not be handled are answered in the batch as well
+			if id := member.Get("id"); id.Exists() && member.Get("method").Exists() {
+				resp, _ := json.Marshal(protocol.NewJSONRPCErrorResponse(json.RawMessage(id.Raw), errorCode(err), err.Error()))
+				batch.Add(resp)
+			}
+		}
+	}
+
+	go func() {
+		defer pkg.Recover()
+
+		message, ok := batch.Wait()
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := client.transport.Send(ctx, message); err != nil {
+			client.logger.Errorf("send batch response error: %s", err.Error())
+		}
+	}()
+	return nil
+}
+
+// rejectBatch answers a batch that can't be handled at all with a single error response with a null id, and returns err
+func (client *Client) rejectBatch(err error) error {
+	message, marshalErr := json.Marshal(protocol.NewJSONRPCErrorResponse(nil, errorCode(err), err.Error()))
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if sendErr := client.transport.Send(ctx, message); sendErr != nil {
+		client.logger.Errorf("send batch error response error: %s", sendErr.Error())
+	}
+	return err
+}
+
+type batchResponsesKey struct{}
+
+// setBatchResponsesToCtx makes the response of the request handled with ctx part of the batch responses
+func setBatchResponsesToCtx(ctx context.Context, batch *pkg.BatchResponses) context.Context {
+	return context.WithValue(ctx, batchResponsesKey{}, batch)
+}
+
+func getBatchResponsesFromCtx(ctx context.Context) (*pkg.BatchResponses, bool) {
+	batch, ok := ctx.Value(batchResponsesKey{}).(*pkg.BatchResponses)
+	return batch, ok
+}
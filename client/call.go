@@ -6,13 +6,50 @@ import (
 	"fmt"
 	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
+// initialization returns when ctx is done without waiting any longer for the initialize request,
+// which must not be cancelled: it stays pending, and completes the initialization if answered later.
 func (client *Client) initialization(ctx context.Context, request *protocol.InitializeRequest) (*protocol.InitializeResult, error) {
-	request.ProtocolVersion = protocol.Version
+	type outcome struct {
+		result *protocol.InitializeResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	go func() {
+		defer pkg.Recover()
+
+		// the request is only given up when the client is closed
+		initCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-client.closed:
+				cancel()
+			case <-initCtx.Done():
+			}
+		}()
+
+		result, err := client.initialize(initCtx, request)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (client *Client) initialize(ctx context.Context, request *protocol.InitializeRequest) (*protocol.InitializeResult, error) {
+	request.ProtocolVersion = client.supportedVersions[0]
 
 	response, err := client.callServer(ctx, protocol.Initialize, request)
 	if err != nil {
@@ -23,20 +60,19 @@ func (client *Client) initialization(ctx context.Context, request *protocol.Init
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	if result.ProtocolVersion != request.ProtocolVersion {
-		return nil, fmt.Errorf("protocol version mismatch, expected %s, got %s", request.ProtocolVersion, result.ProtocolVersion)
+	if !protocol.IsVersionSupported(result.ProtocolVersion, client.supportedVersions) {
+		return nil, fmt.Errorf("protocol version not supported, supported versions are %v, got %s", client.supportedVersions, result.ProtocolVersion)
+	}
+
+	if setter, ok := client.transport.(transport.ProtocolVersionSetter); ok {
+		setter.SetProtocolVersion(result.ProtocolVersion)
 	}
 
 	if err := client.sendNotification4Initialized(ctx); nil != err {
 		return nil, fmt.Errorf("failed to send InitializedNotification: %w", err)
 	}
 
-	client.clientInfo = &request.ClientInfo
-	client.clientCapabilities = &request.Capabilities
-
-	client.serverInfo = &result.ServerInfo
-	client.serverCapabilities = &result.Capabilities
-	client.serverInstructions = result.Instructions
+	client.initResult.Store(&result)
 
 	client.ready.Store(true)
 	return &result, nil
@@ -55,12 +91,18 @@ func (client *Client) Ping(ctx context.Context, request *protocol.PingRequest) (
 	return &result, nil
 }
 
+// ListPrompts returns the first page of prompts, see ListPromptsPage and RangePrompts for paginating servers
 func (client *Client) ListPrompts(ctx context.Context) (*protocol.ListPromptsResult, error) {
-	if client.serverCapabilities.Prompts == nil {
+	return client.ListPromptsPage(ctx, "")
+}
+
+// ListPromptsPage returns the page following cursor, an empty cursor means the first page
+func (client *Client) ListPromptsPage(ctx context.Context, cursor string) (*protocol.ListPromptsResult, error) {
+	if client.GetServerCapabilities().Prompts == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.PromptsList, protocol.NewListPromptsRequest())
+	response, err := client.callServer(ctx, protocol.PromptsList, &protocol.ListPromptsRequest{Cursor: cursor})
 	if err != nil {
 		return nil, err
 	}
@@ -72,12 +114,19 @@ func (client *Client) ListPrompts(ctx context.Context) (*protocol.ListPromptsRes
 	return &result, nil
 }
 
-func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPromptRequest) (*protocol.GetPromptResult, error) {
-	if client.serverCapabilities.Prompts == nil {
+func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPromptRequest, opts ...CallOption) (*protocol.GetPromptResult, error) {
+	if client.GetServerCapabilities().Prompts == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.PromptsGet, request)
+	options := newCallOptions(opts)
+	if options.progressToken != "" {
+		req := *request
+		req.Meta = options.requestMeta(request.Meta)
+		request = &req
+	}
+
+	response, err := client.callServerWithOptions(ctx, protocol.PromptsGet, request, options)
 	if err != nil {
 		return nil, err
 	}
@@ -90,12 +139,18 @@ func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPrompt
 	return &result, nil
 }
 
+// ListResources returns the first page of resources, see ListResourcesPage and RangeResources for paginating servers
 func (client *Client) ListResources(ctx context.Context) (*protocol.ListResourcesResult, error) {
-	if client.serverCapabilities.Resources == nil {
+	return client.ListResourcesPage(ctx, "")
+}
+
+// ListResourcesPage returns the page following cursor, an empty cursor means the first page
+func (client *Client) ListResourcesPage(ctx context.Context, cursor string) (*protocol.ListResourcesResult, error) {
+	if client.GetServerCapabilities().Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesList, protocol.NewListResourcesRequest())
+	response, err := client.callServer(ctx, protocol.ResourcesList, &protocol.ListResourcesRequest{Cursor: cursor})
 	if err != nil {
 		return nil, err
 	}
@@ -107,12 +162,18 @@ func (client *Client) ListResources(ctx context.Context) (*protocol.ListResource
 	return &result, err
 }
 
+// ListResourceTemplates returns the first page of resource templates, see ListResourceTemplatesPage and RangeResourceTemplates for paginating servers
 func (client *Client) ListResourceTemplates(ctx context.Context) (*protocol.ListResourceTemplatesResult, error) {
-	if client.serverCapabilities.Resources == nil {
+	return client.ListResourceTemplatesPage(ctx, "")
+}
+
+// ListResourceTemplatesPage returns the page following cursor, an empty cursor means the first page
+func (client *Client) ListResourceTemplatesPage(ctx context.Context, cursor string) (*protocol.ListResourceTemplatesResult, error) {
+	if client.GetServerCapabilities().Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourceListTemplates, protocol.NewListResourceTemplatesRequest())
+	response, err := client.callServer(ctx, protocol.ResourceListTemplates, &protocol.ListResourceTemplatesRequest{Cursor: cursor})
 	if err != nil {
 		return nil, err
 	}
@@ -124,12 +185,19 @@ func (client *Client) ListResourceTemplates(ctx context.Context) (*protocol.List
 	return &result, nil
 }
 
-func (client *Client) ReadResource(ctx context.Context, request *protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error) {
-	if client.serverCapabilities.Resources == nil {
+func (client *Client) ReadResource(ctx context.Context, request *protocol.ReadResourceRequest, opts ...CallOption) (*protocol.ReadResourceResult, error) {
+	if client.GetServerCapabilities().Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesRead, request)
+	options := newCallOptions(opts)
+	if options.progressToken != "" {
+		req := *request
+		req.Meta = options.requestMeta(request.Meta)
+		request = &req
+	}
+
+	response, err := client.callServerWithOptions(ctx, protocol.ResourcesRead, request, options)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +210,7 @@ func (client *Client) ReadResource(ctx context.Context, request *protocol.ReadRe
 }
 
 func (client *Client) SubscribeResourceChange(ctx context.Context, request *protocol.SubscribeRequest) (*protocol.SubscribeResult, error) {
-	if client.serverCapabilities.Resources == nil || !client.serverCapabilities.Resources.Subscribe {
+	if capabilities := client.GetServerCapabilities(); capabilities.Resources == nil || !capabilities.Resources.Subscribe {
 		return nil, pkg.ErrServerNotSupport
 	}
 
@@ -161,7 +229,7 @@ func (client *Client) SubscribeResourceChange(ctx context.Context, request *prot
 }
 
 func (client *Client) UnSubscribeResourceChange(ctx context.Context, request *protocol.UnsubscribeRequest) (*protocol.UnsubscribeResult, error) {
-	if client.serverCapabilities.Resources == nil || !client.serverCapabilities.Resources.Subscribe {
+	if capabilities := client.GetServerCapabilities(); capabilities.Resources == nil || !capabilities.Resources.Subscribe {
 		return nil, pkg.ErrServerNotSupport
 	}
 
@@ -179,12 +247,18 @@ func (client *Client) UnSubscribeResourceChange(ctx context.Context, request *pr
 	return &result, nil
 }
 
+// ListTools returns the first page of tools, see ListToolsPage and RangeTools for paginating servers
 func (client *Client) ListTools(ctx context.Context) (*protocol.ListToolsResult, error) {
-	if client.serverCapabilities.Tools == nil {
+	return client.ListToolsPage(ctx, "")
+}
+
+// ListToolsPage returns the page following cursor, an empty cursor means the first page
+func (client *Client) ListToolsPage(ctx context.Context, cursor string) (*protocol.ListToolsResult, error) {
+	if client.GetServerCapabilities().Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ToolsList, protocol.NewListToolsRequest())
+	response, err := client.callServer(ctx, protocol.ToolsList, &protocol.ListToolsRequest{Cursor: cursor})
 	if err != nil {
 		return nil, err
 	}
@@ -196,12 +270,19 @@ func (client *Client) ListTools(ctx context.Context) (*protocol.ListToolsResult,
 	return &result, nil
 }
 
-func (client *Client) CallTool(ctx context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
-	if client.serverCapabilities.Tools == nil {
+func (client *Client) CallTool(ctx context.Context, request *protocol.CallToolRequest, opts ...CallOption) (*protocol.CallToolResult, error) {
+	if client.GetServerCapabilities().Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ToolsCall, request)
+	options := newCallOptions(opts)
+	if options.progressToken != "" {
+		req := *request
+		req.Meta = options.requestMeta(request.Meta)
+		request = &req
+	}
+
+	response, err := client.callServerWithOptions(ctx, protocol.ToolsCall, request, options)
 	if err != nil {
 		return nil, err
 	}
@@ -213,32 +294,151 @@ func (client *Client) CallTool(ctx context.Context, request *protocol.CallToolRe
 	return &result, nil
 }
 
+func (client *Client) SetLoggingLevel(ctx context.Context, level protocol.LoggingLevel) (*protocol.SetLoggingLevelResult, error) {
+	if client.GetServerCapabilities().Logging == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	response, err := client.callServer(ctx, protocol.LoggingSetLevel, protocol.NewSetLoggingLevelRequest(level))
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.SetLoggingLevelResult
+	if len(response) > 0 {
+		if err = pkg.JSONUnmarshal(response, &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return &result, nil
+}
+
+// Complete asks the server for completion candidates of the argument argName,
+// ref must be a protocol.PromptReference or a protocol.ResourceReference.
+// The completions capability is not checked, since servers speaking 2024-11-05 do not declare it.
+func (client *Client) Complete(ctx context.Context, ref protocol.CompleteReference, argName string, partial string) (*protocol.CompleteResult, error) {
+	if ref == nil {
+		return nil, fmt.Errorf("completion ref can't is nil")
+	}
+
+	response, err := client.callServer(ctx, protocol.CompletionComplete, protocol.NewCompleteRequest(argName, partial, ref))
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.CompleteResult
+	if err := pkg.JSONUnmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &result, nil
+}
+
 func (client *Client) sendNotification4Initialized(ctx context.Context) error {
 	return client.sendMsgWithNotification(ctx, protocol.NotificationInitialized, protocol.NewInitializedNotification())
 }
 
+func (client *Client) sendNotification4Cancelled(ctx context.Context, requestID protocol.RequestID, reason string) error {
+	return client.sendMsgWithNotification(ctx, protocol.NotificationCancelled, protocol.NewCancelledNotification(requestID, reason))
+}
+
+// cancelRequest tells the server to stop handling the request, the caller has already stopped waiting for it.
+func (client *Client) cancelRequest(requestID protocol.RequestID, reason string) {
+	go func() {
+		defer pkg.Recover()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := client.sendNotification4Cancelled(ctx, requestID, reason); err != nil {
+			client.logger.Warnf("send cancelled notification fail: requestID=%v, err=%v", requestID, err)
+		}
+	}()
+}
+
 // Responsible for request and response assembly
 func (client *Client) callServer(ctx context.Context, method protocol.Method, params protocol.ClientRequest) (json.RawMessage, error) {
+	return client.callServerWithOptions(ctx, method, params, &callOptions{})
+}
+
+func (client *Client) callServerWithOptions(ctx context.Context, method protocol.Method, params protocol.ClientRequest,
+	options *callOptions,
+) (json.RawMessage, error) { //nolint:whitespace
 	if !client.ready.Load() && (method != protocol.Initialize && method != protocol.Ping) {
 		return nil, fmt.Errorf("client not ready")
 	}
 
 	requestID := strconv.FormatInt(atomic.AddInt64(&client.requestID, 1), 10)
 	respChan := make(chan *protocol.JSONRPCResponse, 1)
-	client.reqID2respChan.Set(requestID, respChan)
-	defer client.reqID2respChan.Remove(requestID)
+	client.reqID2respChan.Set(protocol.RequestIDKey(requestID), respChan)
+	defer client.reqID2respChan.Remove(protocol.RequestIDKey(requestID))
+
+	var (
+		progress     *progressDispatcher
+		progressChan <-chan struct{}
+		timer        *time.Timer
+		timeoutChan  <-chan time.Time
+	)
+	if options.progressToken != "" {
+		ch := make(chan struct{}, 1)
+		progress = newProgressDispatcher(func(notify *protocol.ProgressNotification) {
+			options.progressHandler(notify.Progress, notify.Total)
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		})
+		// the progress still queued is dropped when the call fails, the response waits for it instead
+		defer progress.close(true)
+		client.progressToken2handler.Set(options.progressToken, progress.push)
+		defer client.progressToken2handler.Remove(options.progressToken)
+		progressChan = ch
+
+		if options.progressTimeout > 0 {
+			timer = time.NewTimer(options.progressTimeout)
+			defer timer.Stop()
+			timeoutChan = timer.C
+		}
+	}
+
+	connLost := client.getConnLost()
 
 	if err := client.sendMsgWithRequest(ctx, requestID, method, params); err != nil {
 		return nil, fmt.Errorf("callServer: %w", err)
 	}
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case response := <-respChan:
-		if err := response.Error; err != nil {
-			return nil, pkg.NewResponseError(err.Code, err.Message, err.Data)
+	for {
+		select {
+		case <-connLost.done:
+			return nil, fmt.Errorf("callServer: %w", connLost.err)
+		case <-ctx.Done():
+			if method != protocol.Initialize {
+				client.cancelRequest(requestID, ctx.Err().Error())
+			}
+			return nil, ctx.Err()
+		case <-progressChan:
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(options.progressTimeout)
+			}
+		case <-timeoutChan:
+			err := fmt.Errorf("callServer: no progress received within %s", options.progressTimeout)
+			client.cancelRequest(requestID, err.Error())
+			return nil, err
+		case response := <-respChan:
+			if progress != nil {
+				client.progressToken2handler.Remove(options.progressToken)
+				progress.close(false)
+				select {
+				case <-progress.done:
+				case <-ctx.Done():
+				}
+			}
+			if err := response.Error; err != nil {
+				return nil, pkg.NewResponseError(err.Code, err.Message, err.Data)
+			}
+			return response.RawResult, nil
 		}
-		return response.RawResult, nil
 	}
 }
@@ -2,7 +2,10 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	cmap "github.com/orcaman/concurrent-map/v2"
@@ -32,6 +35,44 @@ func WithInitTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithMaxListPages caps the number of pages fetched by RangeTools and the other Range methods, 100 by default,
+// 0 or less meaning no cap
+func WithMaxListPages(maxPages int) Option {
+	return func(s *Client) {
+		s.maxListPages = maxPages
+	}
+}
+
+// WithSamplingHandler declares the sampling capability and handles the sampling/createMessage requests of the server
+func WithSamplingHandler(handler SamplingHandler) Option {
+	return func(s *Client) {
+		s.samplingHandler = handler
+	}
+}
+
+// WithRoots declares the roots capability and answers the roots/list requests of the server with roots,
+// which can be updated later by Client.SetRoots
+func WithRoots(roots ...protocol.Root) Option {
+	return func(s *Client) {
+		s.rootsProvider = newStaticRootsProvider(roots)
+	}
+}
+
+// WithRootsProvider declares the roots capability and answers the roots/list requests of the server with provider
+func WithRootsProvider(provider RootsProvider) Option {
+	return func(s *Client) {
+		s.rootsProvider = provider
+	}
+}
+
+// WithSupportedVersions sets the protocol versions the client supports, latest first.
+// The first one is requested, and initialization fails if the server answers a version not in the list.
+func WithSupportedVersions(versions ...string) Option {
+	return func(s *Client) {
+		s.supportedVersions = versions
+	}
+}
+
 func WithLogger(logger pkg.Logger) Option {
 	return func(s *Client) {
 		s.logger = logger
@@ -43,8 +84,17 @@ type Client struct {
 
 	reqID2respChan cmap.ConcurrentMap[string, chan *protocol.JSONRPCResponse]
 
+	progressToken2handler cmap.ConcurrentMap[string, func(*protocol.ProgressNotification)]
+
+	// cancel functions of the requests from the server that are still being handled
+	reqID2cancel cmap.ConcurrentMap[string, *context.CancelFunc]
+
 	notifyHandler NotifyHandler
 
+	samplingHandler SamplingHandler
+
+	rootsProvider RootsProvider
+
 	requestID int64
 
 	ready *pkg.AtomicBool
@@ -52,11 +102,17 @@ type Client struct {
 	clientInfo         *protocol.Implementation
 	clientCapabilities *protocol.ClientCapabilities
 
-	serverCapabilities *protocol.ServerCapabilities
-	serverInfo         *protocol.Implementation
-	serverInstructions string
+	// result of the latest initialization, replaced while callers read it when the session is initialized again
+	initResult atomic.Value
+
+	supportedVersions []string
+
+	initTimeout  time.Duration
+	maxListPages int
 
-	initTimeout time.Duration
+	// connLost is done when the transport loses the connection, failing the requests waiting for a response
+	connLostMu sync.Mutex
+	connLost   *connLoss
 
 	closed chan struct{}
 
@@ -65,27 +121,47 @@ type Client struct {
 
 func NewClient(t transport.ClientTransport, opts ...Option) (*Client, error) {
 	client := &Client{
-		transport:          t,
-		reqID2respChan:     cmap.New[chan *protocol.JSONRPCResponse](),
-		ready:              pkg.NewAtomicBool(),
-		clientInfo:         &protocol.Implementation{},
-		clientCapabilities: &protocol.ClientCapabilities{},
-		initTimeout:        time.Second * 30,
-		closed:             make(chan struct{}),
-		logger:             pkg.DefaultLogger,
+		transport:             t,
+		reqID2respChan:        cmap.New[chan *protocol.JSONRPCResponse](),
+		progressToken2handler: cmap.New[func(*protocol.ProgressNotification)](),
+		reqID2cancel:          cmap.New[*context.CancelFunc](),
+		ready:                 pkg.NewAtomicBool(),
+		clientInfo:            &protocol.Implementation{},
+		clientCapabilities:    &protocol.ClientCapabilities{},
+		supportedVersions:     protocol.SupportedVersions,
+		initTimeout:           time.Second * 30,
+		maxListPages:          100,
+		connLost:              newConnLoss(),
+		closed:                make(chan struct{}),
+		logger:                pkg.DefaultLogger,
 	}
 	t.SetReceiver(transport.ClientReceiverF(client.receive))
+	if notifier, ok := t.(transport.ConnectionStateNotifier); ok {
+		notifier.AddConnectionStateHandler(client.handleConnectionState)
+	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	if len(client.supportedVersions) == 0 {
+		return nil, errors.New("supported protocol versions can't be empty")
+	}
+
 	if client.notifyHandler == nil {
 		h := NewBaseNotifyHandler()
 		h.Logger = client.logger
 		client.notifyHandler = h
 	}
 
+	if client.samplingHandler != nil {
+		client.clientCapabilities.Sampling = &protocol.SamplingCapability{}
+	}
+
+	if client.rootsProvider != nil {
+		client.clientCapabilities.Roots = &protocol.RootsCapability{ListChanged: true}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), client.initTimeout)
 	defer cancel()
 
@@ -94,6 +170,7 @@ func NewClient(t transport.ClientTransport, opts ...Option) (*Client, error) {
 	}
 
 	if _, err := client.initialization(ctx, protocol.NewInitializeRequest(*client.clientInfo, *client.clientCapabilities)); err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 
@@ -117,15 +194,27 @@ func NewClient(t transport.ClientTransport, opts ...Option) (*Client, error) {
 }
 
 func (client *Client) GetServerCapabilities() protocol.ServerCapabilities {
-	return *client.serverCapabilities
+	return client.getInitResult().Capabilities
 }
 
 func (client *Client) GetServerInfo() protocol.Implementation {
-	return *client.serverInfo
+	return client.getInitResult().ServerInfo
+}
+
+// GetProtocolVersion returns the protocol version negotiated with the server
+func (client *Client) GetProtocolVersion() string {
+	return client.getInitResult().ProtocolVersion
 }
 
 func (client *Client) GetServerInstructions() string {
-	return client.serverInstructions
+	return client.getInitResult().Instructions
+}
+
+func (client *Client) getInitResult() *protocol.InitializeResult {
+	if result, ok := client.initResult.Load().(*protocol.InitializeResult); ok {
+		return result
+	}
+	return &protocol.InitializeResult{}
 }
 
 func (client *Client) Close() error {
@@ -134,6 +223,63 @@ func (client *Client) Close() error {
 	return client.transport.Close()
 }
 
+// handleConnectionState fails the pending requests when the transport loses the connection,
+// and initializes the new session when it reconnected without resuming the previous one.
+func (client *Client) handleConnectionState(state transport.ConnectionState) {
+	switch state {
+	case transport.ConnectionStateSessionLost:
+		client.ready.Store(false)
+		client.failPendingRequests(pkg.ErrConnectionLost)
+
+		go func() {
+			defer pkg.Recover()
+
+			ctx, cancel := context.WithTimeout(context.Background(), client.initTimeout)
+			defer cancel()
+
+			if _, err := client.initialization(ctx, protocol.NewInitializeRequest(*client.clientInfo, *client.clientCapabilities)); err != nil {
+				client.logger.Errorf("mcp client reinitialize session fail: %v", err)
+			}
+		}()
+	case transport.ConnectionStateDisconnected:
+		client.ready.Store(false)
+
+		err := pkg.ErrConnectionLost
+		if reporter, ok := client.transport.(transport.ConnectionErrorReporter); ok {
+			if cause := reporter.ConnectionError(); cause != nil {
+				err = cause
+			}
+		}
+		client.failPendingRequests(err)
+	}
+}
+
+// connLoss is the loss of the connection, done is closed once err is set
+type connLoss struct {
+	done chan struct{}
+	err  error
+}
+
+func newConnLoss() *connLoss {
+	return &connLoss{done: make(chan struct{})}
+}
+
+func (client *Client) getConnLost() *connLoss {
+	client.connLostMu.Lock()
+	defer client.connLostMu.Unlock()
+
+	return client.connLost
+}
+
+func (client *Client) failPendingRequests(err error) {
+	client.connLostMu.Lock()
+	defer client.connLostMu.Unlock()
+
+	client.connLost.err = err
+	close(client.connLost.done)
+	client.connLost = newConnLoss()
+}
+
 func (client *Client) sessionDetection() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
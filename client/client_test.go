@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
@@ -150,6 +152,23 @@ func TestClientCall(t *testing.T) {
 			}),
 			expectedResponse: protocol.NewCallToolResult([]protocol.Content{protocol.TextContent{Type: "text", Text: "success"}}, false),
 		},
+		{
+			name: "test_set_logging_level",
+			f: func(client *Client, request protocol.ClientRequest) (protocol.ServerResponse, error) {
+				return client.SetLoggingLevel(context.Background(), request.(*protocol.SetLoggingLevelRequest).Level)
+			},
+			request:          protocol.NewSetLoggingLevelRequest(protocol.LogWarning),
+			expectedResponse: protocol.NewSetLoggingLevelResult(true),
+		},
+		{
+			name: "test_complete",
+			f: func(client *Client, request protocol.ClientRequest) (protocol.ServerResponse, error) {
+				req := request.(*protocol.CompleteRequest)
+				return client.Complete(context.Background(), req.Ref, req.Argument.Name, req.Argument.Value)
+			},
+			request:          protocol.NewCompleteRequest("language", "py", protocol.NewPromptReference("code_review")),
+			expectedResponse: protocol.NewCompleteResult([]string{"python", "pytorch"}, true, 10),
+		},
 	}
 
 	for _, tt := range tests {
@@ -216,13 +235,19 @@ func TestClientCall(t *testing.T) {
 }
 
 func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outScan *bufio.Scanner) *Client {
+	return testClientInitWithVersion(t, in, out, outScan, protocol.Version, protocol.ClientCapabilities{})
+}
+
+func testClientInitWithVersion(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outScan *bufio.Scanner,
+	version string, capabilities protocol.ClientCapabilities, opts ...Option,
+) *Client {
 	req := protocol.InitializeRequest{
 		ClientInfo: protocol.Implementation{
 			Name:    "test_client",
 			Version: "0.1",
 		},
-		Capabilities:    protocol.ClientCapabilities{},
-		ProtocolVersion: protocol.Version,
+		Capabilities:    capabilities,
+		ProtocolVersion: version,
 	}
 
 	ch := make(chan struct{})
@@ -271,6 +296,7 @@ func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outS
 				Version: "0.1",
 			},
 			Capabilities: protocol.ServerCapabilities{
+				Logging: &protocol.LoggingCapability{},
 				Prompts: &protocol.PromptsCapability{
 					ListChanged: true,
 				},
@@ -282,7 +308,7 @@ func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outS
 					ListChanged: true,
 				},
 			},
-			ProtocolVersion: protocol.Version,
+			ProtocolVersion: version,
 		}
 
 		respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, resp))
@@ -306,10 +332,607 @@ func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outS
 		ch <- struct{}{}
 	}()
 
-	client, err := NewClient(transport.NewMockClientTransport(in, out), WithClientInfo(req.ClientInfo))
+	client, err := NewClient(transport.NewMockClientTransport(in, out), append([]Option{WithClientInfo(req.ClientInfo)}, opts...)...)
 	if err != nil {
 		t.Fatalf("NewServer: %+v", err)
 	}
 	<-ch
 	return client
 }
+
+// testClientConn is the server end of a client over a mock transport,
+// what is written to in is received by the client and the client's messages are read line by line from out
+type testClientConn struct {
+	in  io.Writer
+	out *bufio.Scanner
+}
+
+// newTestClient creates a client over a mock transport and answers its initialization
+func newTestClient(t *testing.T, capabilities protocol.ClientCapabilities, opts ...Option) (*Client, *testClientConn) {
+	t.Helper()
+
+	return newTestClientWithVersion(t, protocol.Version, capabilities, opts...)
+}
+
+// newTestClientWithVersion creates a client over a mock transport which only supports version
+func newTestClientWithVersion(t *testing.T, version string, capabilities protocol.ClientCapabilities, opts ...Option) (*Client, *testClientConn) {
+	t.Helper()
+
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	in := struct {
+		io.Reader
+		io.Writer
+		io.Closer
+	}{
+		Reader: reader1,
+		Writer: writer1,
+		Closer: reader1,
+	}
+	out := struct {
+		io.Reader
+		io.Writer
+	}{
+		Reader: reader2,
+		Writer: writer2,
+	}
+
+	conn := &testClientConn{in: writer1, out: bufio.NewScanner(out)}
+	client := testClientInitWithVersion(t, in, out, conn.out, version, capabilities,
+		append([]Option{WithSupportedVersions(version)}, opts...)...)
+	return client, conn
+}
+
+// write and read return errors instead of failing the test, they are also used in goroutines other than the test's
+func (c *testClientConn) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json Marshal: %w", err)
+	}
+	if _, err = c.in.Write(append(b, "\n"...)); err != nil {
+		return fmt.Errorf("in Write: %w", err)
+	}
+	return nil
+}
+
+func (c *testClientConn) read() ([]byte, error) {
+	if !c.out.Scan() {
+		return nil, fmt.Errorf("outScan: %+v", c.out.Err())
+	}
+	return c.out.Bytes(), nil
+}
+
+func (c *testClientConn) readRequest() (*protocol.JSONRPCRequest, error) {
+	b, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	req := &protocol.JSONRPCRequest{}
+	if err = pkg.JSONUnmarshal(b, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (c *testClientConn) readNotify() (*protocol.JSONRPCNotification, error) {
+	b, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	notify := &protocol.JSONRPCNotification{}
+	if err = pkg.JSONUnmarshal(b, notify); err != nil {
+		return nil, err
+	}
+	return notify, nil
+}
+
+func (c *testClientConn) readResponse() (*protocol.JSONRPCResponse, error) {
+	b, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	resp := &protocol.JSONRPCResponse{}
+	if err = pkg.JSONUnmarshal(b, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func TestClientCallWithProgress(t *testing.T) {
+	client, conn := newTestClient(t, protocol.ClientCapabilities{})
+
+	expectedResponse := protocol.NewCallToolResult([]protocol.Content{protocol.TextContent{Type: "text", Text: "success"}}, false)
+
+	go func() {
+		jsonrpcReq, err := conn.readRequest()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		request := &protocol.CallToolRequest{}
+		if err := pkg.JSONUnmarshal(jsonrpcReq.RawParams, request); err != nil {
+			t.Errorf("Json Unmarshal: %+v", err)
+			return
+		}
+		if request.Meta == nil || request.Meta.ProgressToken == nil {
+			t.Errorf("progress token not injected: %s", jsonrpcReq.RawParams)
+			return
+		}
+
+		for _, progress := range []float64{1, 2} {
+			if err := conn.write(protocol.NewJSONRPCNotification(protocol.NotificationProgress,
+				protocol.NewProgressNotification(request.Meta.ProgressToken, progress, 2))); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		if err := conn.write(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, expectedResponse)); err != nil {
+			t.Error(err)
+			return
+		}
+	}()
+
+	var progresses []float64
+	response, err := client.CallTool(context.Background(), protocol.NewCallToolRequest("test_tool", nil),
+		WithProgress(func(progress float64, _ float64) {
+			progresses = append(progresses, progress)
+		}))
+	if err != nil {
+		t.Fatalf("CallTool: %+v", err)
+	}
+
+	if !reflect.DeepEqual(response, expectedResponse) {
+		t.Fatalf("response not as expected.\ngot  = %+v\nwant = %+v", response, expectedResponse)
+	}
+	if !reflect.DeepEqual(progresses, []float64{1, 2}) {
+		t.Fatalf("progress not as expected.\ngot  = %+v\nwant = %+v", progresses, []float64{1, 2})
+	}
+}
+
+func TestClientProgressTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		progresses  int
+		expectedErr bool
+	}{
+		// the call lasts 6 times the timeout, each progress restarting the timer
+		{name: "test_progress_reported", progresses: 12},
+		{name: "test_progress_stalled", progresses: 0, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, conn := newTestClient(t, protocol.ClientCapabilities{})
+
+			go func() {
+				jsonrpcReq, err := conn.readRequest()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				request := &protocol.CallToolRequest{}
+				if err := pkg.JSONUnmarshal(jsonrpcReq.RawParams, request); err != nil {
+					t.Errorf("Json Unmarshal: %+v", err)
+					return
+				}
+				for i := 0; i < tt.progresses; i++ {
+					time.Sleep(50 * time.Millisecond)
+					if err := conn.write(protocol.NewJSONRPCNotification(protocol.NotificationProgress,
+						protocol.NewProgressNotification(request.Meta.ProgressToken, float64(i+1), float64(tt.progresses)))); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+				if tt.expectedErr {
+					return
+				}
+				if err := conn.write(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, protocol.NewCallToolResult(nil, false))); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			_, err := client.CallTool(context.Background(), protocol.NewCallToolRequest("test_tool", nil),
+				WithProgress(func(float64, float64) {}), WithProgressTimeout(100*time.Millisecond))
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("CallTool error: got %v, want error %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestClientSlowProgressHandler(t *testing.T) {
+	client, conn := newTestClient(t, protocol.ClientCapabilities{})
+
+	errCh := make(chan error, 1)
+	go func() {
+		callReq, err := conn.readRequest()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		request := &protocol.CallToolRequest{}
+		if err := pkg.JSONUnmarshal(callReq.RawParams, request); err != nil {
+			errCh <- err
+			return
+		}
+		if err := conn.write(protocol.NewJSONRPCNotification(protocol.NotificationProgress,
+			protocol.NewProgressNotification(request.Meta.ProgressToken, 1, 2))); err != nil {
+			errCh <- err
+			return
+		}
+
+		// the ping is answered while the progress handler is still blocked
+		pingReq, err := conn.readRequest()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if err := conn.write(protocol.NewJSONRPCSuccessResponse(pingReq.ID, protocol.NewPingResult())); err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- conn.write(protocol.NewJSONRPCSuccessResponse(callReq.ID, protocol.NewCallToolResult(nil, false)))
+	}()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var progresses []float64
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(context.Background(), protocol.NewCallToolRequest("test_tool", nil),
+			WithProgress(func(progress float64, _ float64) {
+				close(started)
+				<-release
+				progresses = append(progresses, progress)
+			}))
+		callErr <- err
+	}()
+
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Ping(ctx, protocol.NewPingRequest()); err != nil {
+		t.Fatalf("Ping blocked by the progress handler: %+v", err)
+	}
+	close(release)
+
+	if err := <-callErr; err != nil {
+		t.Fatalf("CallTool: %+v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(progresses, []float64{1}) {
+		t.Fatalf("progress not as expected.\ngot  = %+v\nwant = %+v", progresses, []float64{1})
+	}
+}
+
+func TestClientCancelRequest(t *testing.T) {
+	client, conn := newTestClient(t, protocol.ClientCapabilities{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		jsonrpcReq, err := conn.readRequest()
+		if err != nil {
+			errCh <- err
+			return
+		}
+
+		cancel()
+
+		notify, err := conn.readNotify()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		var cancelled protocol.CancelledNotification
+		if err := pkg.JSONUnmarshal(notify.RawParams, &cancelled); err != nil {
+			errCh <- err
+			return
+		}
+		if notify.Method != protocol.NotificationCancelled || cancelled.RequestID != jsonrpcReq.ID {
+			errCh <- fmt.Errorf("notify not as expected: %+v", notify)
+			return
+		}
+		errCh <- nil
+	}()
+
+	if _, err := client.CallTool(ctx, protocol.NewCallToolRequest("test_tool", nil)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CallTool error not as expected: %+v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientBatch(t *testing.T) {
+	client, conn := newTestClientWithVersion(t, protocol.Version20250326, protocol.ClientCapabilities{})
+
+	errCh := make(chan error, 1)
+	go func() {
+		b, err := conn.read()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		var reqs []*protocol.JSONRPCRequest
+		if err := pkg.JSONUnmarshal(b, &reqs); err != nil {
+			errCh <- err
+			return
+		}
+		if len(reqs) != 2 || reqs[0].Method != protocol.Ping || reqs[1].Method != protocol.ToolsList {
+			errCh <- fmt.Errorf("batch not as expected: %s", b)
+			return
+		}
+
+		// answered in reverse order, the responses are matched by id
+		errCh <- conn.write([]*protocol.JSONRPCResponse{
+			protocol.NewJSONRPCErrorResponse(reqs[1].ID, protocol.MethodNotFound, "not found"),
+			protocol.NewJSONRPCSuccessResponse(reqs[0].ID, protocol.NewPingResult()),
+		})
+	}()
+
+	calls := []*BatchCall{
+		NewBatchCall(protocol.Ping, protocol.NewPingRequest()),
+		NewBatchCall(protocol.ToolsList, protocol.NewListToolsRequest()),
+	}
+	if err := client.CallBatch(context.Background(), calls...); err != nil {
+		t.Fatalf("CallBatch: %+v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	if calls[0].Err != nil || calls[0].Result == nil {
+		t.Fatalf("ping call not as expected: %+v", calls[0])
+	}
+	var respErr *pkg.ResponseError
+	if !errors.As(calls[1].Err, &respErr) || respErr.Code != protocol.MethodNotFound {
+		t.Fatalf("tools call error not as expected: %+v", calls[1].Err)
+	}
+}
+
+func TestClientBatchNotSupported(t *testing.T) {
+	client, conn := newTestClientWithVersion(t, protocol.Version20241105, protocol.ClientCapabilities{})
+
+	// batching was added after the protocol version negotiated
+	if err := client.CallBatch(context.Background(), NewBatchCall(protocol.Ping, protocol.NewPingRequest())); err == nil {
+		t.Fatal("CallBatch: expect error")
+	}
+
+	if err := conn.write([]interface{}{protocol.NewJSONRPCRequest("ping", protocol.Ping, protocol.NewPingRequest())}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := conn.readResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != nil || resp.Error == nil || resp.Error.Code != protocol.InvalidRequest {
+		t.Fatalf("response not as expected.\ngot  = %+v\nwant invalid request error", resp)
+	}
+}
+
+func TestClientSampling(t *testing.T) {
+	expectedResult := protocol.NewCreateMessageResult(protocol.TextContent{Type: "text", Text: "summary"}, protocol.RoleAssistant, "test-model", "endTurn")
+
+	tests := []struct {
+		name             string
+		handler          SamplingHandlerFunc
+		expectedErrCode  int
+		expectedResponse *protocol.CreateMessageResult
+	}{
+		{
+			name: "test_sampling_accepted",
+			handler: func(_ context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+				if len(request.Messages) != 1 || request.Messages[0].Content != (protocol.TextContent{Type: "text", Text: "summarize it"}) {
+					return nil, fmt.Errorf("request not as expected: %+v", request)
+				}
+				return expectedResult, nil
+			},
+			expectedResponse: expectedResult,
+		},
+		{
+			name: "test_sampling_rejected",
+			handler: func(_ context.Context, _ *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+				return nil, pkg.ErrSamplingRejected
+			},
+			expectedErrCode: protocol.UserRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, conn := newTestClient(t, protocol.ClientCapabilities{Sampling: &protocol.SamplingCapability{}}, WithSamplingHandler(tt.handler))
+
+			request := protocol.NewCreateMessageRequest([]protocol.SamplingMessage{
+				{Role: protocol.RoleUser, Content: protocol.TextContent{Type: "text", Text: "summarize it"}},
+			}, 100)
+			if err := conn.write(protocol.NewJSONRPCRequest("sampling", protocol.SamplingCreateMessage, request)); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := conn.readResponse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expectedResponse == nil {
+				if resp.Error == nil || resp.Error.Code != tt.expectedErrCode {
+					t.Fatalf("response not as expected.\ngot  = %+v\nwant error code %d", resp, tt.expectedErrCode)
+				}
+				return
+			}
+
+			if resp.Error != nil {
+				t.Fatalf("response error: %+v", resp.Error)
+			}
+			var result protocol.CreateMessageResult
+			if err = pkg.JSONUnmarshal(resp.RawResult, &result); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&result, tt.expectedResponse) {
+				t.Fatalf("response not as expected.\ngot  = %+v\nwant = %+v", result, tt.expectedResponse)
+			}
+		})
+	}
+}
+
+func TestClientCancelSamplingIDType(t *testing.T) {
+	handlerDone := make(chan error, 1)
+	handler := func(ctx context.Context, _ *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+		select {
+		case <-ctx.Done():
+			handlerDone <- errors.New("sampling cancelled by the cancellation of another request id")
+		case <-time.After(200 * time.Millisecond):
+			handlerDone <- nil
+		}
+		return protocol.NewCreateMessageResult(protocol.TextContent{Type: "text", Text: "summary"}, protocol.RoleAssistant, "test-model", "endTurn"), nil
+	}
+	_, conn := newTestClient(t, protocol.ClientCapabilities{Sampling: &protocol.SamplingCapability{}}, WithSamplingHandler(SamplingHandlerFunc(handler)))
+
+	request := protocol.NewCreateMessageRequest([]protocol.SamplingMessage{
+		{Role: protocol.RoleUser, Content: protocol.TextContent{Type: "text", Text: "summarize it"}},
+	}, 100)
+	// the string id "1" is not the same request as the numeric id 1
+	if err := conn.write(protocol.NewJSONRPCRequest(1, protocol.SamplingCreateMessage, request)); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.write(protocol.NewJSONRPCNotification(protocol.NotificationCancelled, protocol.NewCancelledNotification("1", "test"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-handlerDone; err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := conn.readResponse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("response error: %+v", resp.Error)
+	}
+}
+
+func TestClientRoots(t *testing.T) {
+	roots := []protocol.Root{{Name: "workspace", URI: "file:///workspace"}}
+	client, conn := newTestClient(t, protocol.ClientCapabilities{Roots: &protocol.RootsCapability{ListChanged: true}}, WithRoots(roots...))
+
+	listRoots := func(id string) *protocol.ListRootsResult {
+		if err := conn.write(protocol.NewJSONRPCRequest(id, protocol.RootsList, protocol.NewListRootsRequest())); err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := conn.readResponse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != nil {
+			t.Fatalf("response error: %+v", resp.Error)
+		}
+		var result protocol.ListRootsResult
+		if err = pkg.JSONUnmarshal(resp.RawResult, &result); err != nil {
+			t.Fatal(err)
+		}
+		return &result
+	}
+
+	if result := listRoots("list1"); !reflect.DeepEqual(result.Roots, roots) {
+		t.Fatalf("roots not as expected.\ngot  = %+v\nwant = %+v", result.Roots, roots)
+	}
+
+	newRoots := []protocol.Root{{Name: "other", URI: "file:///other"}}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.SetRoots(context.Background(), newRoots)
+	}()
+
+	notify, err := conn.readNotify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notify.Method != protocol.NotificationRootsListChanged {
+		t.Fatalf("notify not as expected: %+v", notify)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("SetRoots: %+v", err)
+	}
+
+	if result := listRoots("list2"); !reflect.DeepEqual(result.Roots, newRoots) {
+		t.Fatalf("roots not as expected.\ngot  = %+v\nwant = %+v", result.Roots, newRoots)
+	}
+}
+
+func TestClientSessionLost(t *testing.T) {
+	client, conn := newTestClient(t, protocol.ClientCapabilities{})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Ping(context.Background(), protocol.NewPingRequest())
+		errCh <- err
+	}()
+	if _, err := conn.read(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server info is read while the new session is initialized, which the race detector checks
+	stopReading := make(chan struct{})
+	readingDone := make(chan struct{})
+	go func() {
+		defer close(readingDone)
+		for {
+			select {
+			case <-stopReading:
+				return
+			default:
+				_ = client.GetServerInfo()
+				_ = client.GetServerCapabilities()
+				_ = client.GetServerInstructions()
+				_ = client.GetProtocolVersion()
+			}
+		}
+	}()
+	defer func() {
+		close(stopReading)
+		<-readingDone
+	}()
+
+	// the pending request fails, and the new session is initialized
+	client.handleConnectionState(transport.ConnectionStateSessionLost)
+	if err := <-errCh; !errors.Is(err, pkg.ErrConnectionLost) {
+		t.Fatalf("Ping error got %v, want %v", err, pkg.ErrConnectionLost)
+	}
+
+	req, err := conn.readRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != protocol.Initialize {
+		t.Fatalf("request not as expected: %+v", req)
+	}
+	if err = conn.write(protocol.NewJSONRPCSuccessResponse(req.ID, &protocol.InitializeResult{
+		ServerInfo:      protocol.Implementation{Name: "test_server", Version: "0.2"},
+		ProtocolVersion: protocol.Version,
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	notify, err := conn.readNotify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notify.Method != protocol.NotificationInitialized {
+		t.Fatalf("notify not as expected: %+v", notify)
+	}
+
+	// the result of the new initialization is published right after the notification is sent
+	deadline := time.Now().Add(time.Second)
+	for client.GetServerInfo().Version != "0.2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("server info not as expected.\ngot  = %+v\nwant version 0.2", client.GetServerInfo())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
@@ -12,6 +13,40 @@ func (client *Client) handleRequestWithPing() (*protocol.PingResult, error) {
 	return protocol.NewPingResult(), nil
 }
 
+func (client *Client) handleRequestWithListRoots(ctx context.Context, rawParams json.RawMessage) (*protocol.ListRootsResult, error) {
+	if client.rootsProvider == nil {
+		return nil, fmt.Errorf("%w: roots provider not set", pkg.ErrMethodNotSupport)
+	}
+
+	request := &protocol.ListRootsRequest{}
+	if len(rawParams) > 0 {
+		if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
+			return nil, err
+		}
+	}
+
+	roots, err := client.rootsProvider.ListRoots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if roots == nil {
+		roots = []protocol.Root{}
+	}
+	return protocol.NewListRootsResult(roots), nil
+}
+
+func (client *Client) handleRequestWithCreateMessagesSampling(ctx context.Context, rawParams json.RawMessage) (*protocol.CreateMessageResult, error) {
+	if client.samplingHandler == nil {
+		return nil, fmt.Errorf("%w: sampling handler not set", pkg.ErrMethodNotSupport)
+	}
+
+	request := &protocol.CreateMessageRequest{}
+	if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
+		return nil, err
+	}
+	return client.samplingHandler.CreateMessage(ctx, request)
+}
+
 func (client *Client) handleNotifyWithToolsListChanged(ctx context.Context, rawParams json.RawMessage) error {
 	notify := &protocol.ToolListChangedNotification{}
 	if len(rawParams) > 0 {
@@ -51,3 +86,25 @@ func (client *Client) handleNotifyWithResourcesUpdated(ctx context.Context, rawP
 	}
 	return client.notifyHandler.ResourcesUpdated(ctx, notify)
 }
+
+func (client *Client) handleNotifyWithLogMessage(ctx context.Context, rawParams json.RawMessage) error {
+	notify := &protocol.LogMessageNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+		return err
+	}
+	return client.notifyHandler.LogMessage(ctx, notify)
+}
+
+func (client *Client) handleNotifyWithCancelled(_ context.Context, rawParams json.RawMessage) error {
+	notify := &protocol.CancelledNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+		return err
+	}
+
+	// the request may already be finished, in which case the notification is ignored
+	if cancel, ok := client.reqID2cancel.Get(protocol.RequestIDKey(notify.RequestID)); ok {
+		client.logger.Debugf("cancel request: requestID=%v, reason=%s", notify.RequestID, notify.Reason)
+		(*cancel)()
+	}
+	return nil
+}
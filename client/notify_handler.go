@@ -15,6 +15,7 @@ type NotifyHandler interface {
 	PromptListChanged(ctx context.Context, request *protocol.PromptListChangedNotification) error
 	ResourceListChanged(ctx context.Context, request *protocol.ResourceListChangedNotification) error
 	ResourcesUpdated(ctx context.Context, request *protocol.ResourceUpdatedNotification) error
+	LogMessage(ctx context.Context, request *protocol.LogMessageNotification) error
 }
 
 type BaseNotifyHandler struct {
@@ -41,6 +42,10 @@ func (handler *BaseNotifyHandler) ResourcesUpdated(_ context.Context, request *p
 	return handler.defaultNotifyHandler(protocol.NotificationResourcesUpdated, request)
 }
 
+func (handler *BaseNotifyHandler) LogMessage(_ context.Context, request *protocol.LogMessageNotification) error {
+	return handler.defaultNotifyHandler(protocol.NotificationLogMessage, request)
+}
+
 func (handler *BaseNotifyHandler) defaultNotifyHandler(method protocol.Method, notify interface{}) error {
 	b, err := json.Marshal(notify)
 	if err != nil {
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// RangeTools calls f for each tool of every page in turn, until f returns false or there are no more pages
+func (client *Client) RangeTools(ctx context.Context, f func(tool *protocol.Tool) bool) error {
+	return client.rangePages(func(cursor string) (string, bool, error) {
+		result, err := client.ListToolsPage(ctx, cursor)
+		if err != nil {
+			return "", false, err
+		}
+		for _, tool := range result.Tools {
+			if !f(tool) {
+				return "", false, nil
+			}
+		}
+		return result.NextCursor, true, nil
+	})
+}
+
+// RangePrompts calls f for each prompt of every page in turn, until f returns false or there are no more pages
+func (client *Client) RangePrompts(ctx context.Context, f func(prompt protocol.Prompt) bool) error {
+	return client.rangePages(func(cursor string) (string, bool, error) {
+		result, err := client.ListPromptsPage(ctx, cursor)
+		if err != nil {
+			return "", false, err
+		}
+		for _, prompt := range result.Prompts {
+			if !f(prompt) {
+				return "", false, nil
+			}
+		}
+		return result.NextCursor, true, nil
+	})
+}
+
+// RangeResources calls f for each resource of every page in turn, until f returns false or there are no more pages
+func (client *Client) RangeResources(ctx context.Context, f func(resource protocol.Resource) bool) error {
+	return client.rangePages(func(cursor string) (string, bool, error) {
+		result, err := client.ListResourcesPage(ctx, cursor)
+		if err != nil {
+			return "", false, err
+		}
+		for _, resource := range result.Resources {
+			if !f(resource) {
+				return "", false, nil
+			}
+		}
+		return result.NextCursor, true, nil
+	})
+}
+
+// RangeResourceTemplates calls f for each resource template of every page in turn, until f returns false or there are no more pages
+func (client *Client) RangeResourceTemplates(ctx context.Context, f func(template protocol.ResourceTemplate) bool) error {
+	return client.rangePages(func(cursor string) (string, bool, error) {
+		result, err := client.ListResourceTemplatesPage(ctx, cursor)
+		if err != nil {
+			return "", false, err
+		}
+		for _, template := range result.ResourceTemplates {
+			if !f(template) {
+				return "", false, nil
+			}
+		}
+		return result.NextCursor, true, nil
+	})
+}
+
+// rangePages fetches pages until the server returns no next cursor, or fetch asks to stop.
+// The number of pages is capped, so that a misbehaving server cannot keep the client looping forever, unless the cap is disabled.
+func (client *Client) rangePages(fetch func(cursor string) (nextCursor string, more bool, err error)) error {
+	cursor := ""
+	for i := 0; client.maxListPages <= 0 || i < client.maxListPages; i++ {
+		nextCursor, more, err := fetch(cursor)
+		if err != nil {
+			return err
+		}
+		if !more || nextCursor == "" {
+			return nil
+		}
+		cursor = nextCursor
+	}
+	return fmt.Errorf("list exceeds the maximum of %d pages", client.maxListPages)
+}
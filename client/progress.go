@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// ProgressHandler receives the progress of a call, total is 0 when the server does not know it.
+// It is invoked in order on a goroutine of the call, and the progress received before the response
+// is all handled before the call returns.
+type ProgressHandler func(progress float64, total float64)
+
+type CallOption func(*callOptions)
+
+// WithProgress asks the server to report the progress of the call to handler
+func WithProgress(handler ProgressHandler) CallOption {
+	return func(o *callOptions) {
+		o.progressHandler = handler
+	}
+}
+
+// WithProgressTimeout fails the call when neither progress nor response arrives within timeout,
+// every progress notification restarts the timer. It only takes effect together with WithProgress.
+// The progress can't extend the deadline of the ctx of the call, so a long call kept alive by its progress
+// is made with a ctx without deadline, or with one bounding the whole call.
+func WithProgressTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.progressTimeout = timeout
+	}
+}
+
+type callOptions struct {
+	progressHandler ProgressHandler
+	progressTimeout time.Duration
+
+	progressToken string
+}
+
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.progressHandler != nil {
+		o.progressToken = uuid.NewString()
+	}
+	return o
+}
+
+// requestMeta returns a copy of meta carrying the progress token of the call
+func (o *callOptions) requestMeta(meta *protocol.RequestMeta) *protocol.RequestMeta {
+	if o.progressToken == "" {
+		return meta
+	}
+	m := &protocol.RequestMeta{}
+	if meta != nil {
+		*m = *meta
+	}
+	m.ProgressToken = o.progressToken
+	return m
+}
+
+// progressDispatcher runs the progress handler of a call off the receiving goroutine, in the order the notifications arrived
+type progressDispatcher struct {
+	handler func(*protocol.ProgressNotification)
+
+	mu        sync.Mutex
+	queue     []*protocol.ProgressNotification
+	closed    bool
+	discarded bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func newProgressDispatcher(handler func(*protocol.ProgressNotification)) *progressDispatcher {
+	d := &progressDispatcher{
+		handler: handler,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// push queues notify without blocking, it is dropped once the dispatcher is closed
+func (d *progressDispatcher) push(notify *protocol.ProgressNotification) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.queue = append(d.queue, notify)
+	d.mu.Unlock()
+
+	d.signal()
+}
+
+// close stops the dispatcher once the queued notifications are handled, or right away when discard is set
+func (d *progressDispatcher) close(discard bool) {
+	d.mu.Lock()
+	d.closed = true
+	if discard {
+		d.queue = nil
+		d.discarded = true
+	}
+	d.mu.Unlock()
+
+	d.signal()
+}
+
+func (d *progressDispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *progressDispatcher) run() {
+	defer pkg.Recover()
+	defer close(d.done)
+
+	for {
+		d.mu.Lock()
+		queue, closed := d.queue, d.closed
+		d.queue = nil
+		d.mu.Unlock()
+
+		for _, notify := range queue {
+			if d.isDiscarded() {
+				break
+			}
+			d.handler(notify)
+		}
+		if len(queue) == 0 {
+			if closed {
+				return
+			}
+			<-d.wake
+		}
+	}
+}
+
+func (d *progressDispatcher) isDiscarded() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.discarded
+}
+
+func (client *Client) handleNotifyWithProgress(_ context.Context, rawParams json.RawMessage) error {
+	notify := &protocol.ProgressNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, notify); err != nil {
+		return err
+	}
+
+	handler, ok := client.progressToken2handler.Get(fmt.Sprint(notify.ProgressToken))
+	if !ok {
+		// the call may have finished already, late notifications are ignored
+		return nil
+	}
+	handler(notify)
+	return nil
+}
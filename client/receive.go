@@ -14,11 +14,27 @@ import (
 func (client *Client) receive(_ context.Context, msg []byte) error {
 	defer pkg.Recover()
 
+	if pkg.IsBatch(msg) {
+		return client.receiveBatch(msg)
+	}
+	return client.receiveMessage(msg, nil)
+}
+
+// receiveMessage handles a single message, the response of a request is added to batch when it is a member of one
+func (client *Client) receiveMessage(msg []byte, batch *pkg.BatchResponses) error {
 	if !gjson.GetBytes(msg, "id").Exists() {
 		notify := &protocol.JSONRPCNotification{}
 		if err := pkg.JSONUnmarshal(msg, &notify); err != nil {
 			return err
 		}
+		if notify.Method == protocol.NotificationProgress {
+			// queued in order, so that progress callbacks are not reordered and always precede the response
+			if err := client.receiveNotify(context.Background(), notify); err != nil {
+				notify.RawParams = nil // simplified log
+				client.logger.Errorf("receive notify:%+v error: %s", notify, err.Error())
+			}
+			return nil
+		}
 		go func() {
 			defer pkg.Recover()
 
@@ -56,10 +72,29 @@ func (client *Client) receive(_ context.Context, msg []byte) error {
 	if !req.IsValid() {
 		return pkg.ErrRequestInvalid
 	}
+
+	// registered before the handler goroutine starts, as the server may give up on a request at any time,
+	// such as a sampling request waiting for the user
+	ctx, cancel := context.WithCancel(context.Background())
+	reqKey := protocol.RequestIDKey(req.ID)
+	client.reqID2cancel.Set(reqKey, &cancel)
+
+	if batch != nil {
+		ctx = setBatchResponsesToCtx(ctx, batch)
+		batch.Pending()
+	}
+
 	go func() {
 		defer pkg.Recover()
+		defer client.reqID2cancel.RemoveCb(reqKey, func(_ string, v *context.CancelFunc, exists bool) bool {
+			return exists && v == &cancel
+		})
+		if batch != nil {
+			defer batch.Done()
+		}
+		defer cancel()
 
-		if err := client.receiveRequest(context.Background(), req); err != nil {
+		if err := client.receiveRequest(ctx, req); err != nil {
 			req.RawParams = nil // simplified log
 			client.logger.Errorf("receive request:%+v error: %s", req, err.Error())
 			return
@@ -77,29 +112,42 @@ func (client *Client) receiveRequest(ctx context.Context, request *protocol.JSON
 	switch request.Method {
 	case protocol.Ping:
 		result, err = client.handleRequestWithPing()
-	// case protocol.RootsList:
-	// 	result, err = client.handleRequestWithListRoots(ctx, request.RawParams)
-	// case protocol.SamplingCreateMessage:
-	// 	result, err = client.handleRequestWithCreateMessagesSampling(ctx, request.RawParams)
+	case protocol.RootsList:
+		result, err = client.handleRequestWithListRoots(ctx, request.RawParams)
+	case protocol.SamplingCreateMessage:
+		result, err = client.handleRequestWithCreateMessagesSampling(ctx, request.RawParams)
 	default:
 		err = fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, request.Method)
 	}
 
+	if ctx.Err() != nil {
+		// the request has been cancelled by the server, which no longer expects a response
+		client.logger.Debugf("request cancelled, response suppressed: requestID=%v", request.ID)
+		return nil
+	}
+
 	if err != nil {
-		switch {
-		case errors.Is(err, pkg.ErrMethodNotSupport):
-			return client.sendMsgWithError(ctx, request.ID, protocol.MethodNotFound, err.Error())
-		case errors.Is(err, pkg.ErrRequestInvalid):
-			return client.sendMsgWithError(ctx, request.ID, protocol.InvalidRequest, err.Error())
-		case errors.Is(err, pkg.ErrJSONUnmarshal):
-			return client.sendMsgWithError(ctx, request.ID, protocol.ParseError, err.Error())
-		default:
-			return client.sendMsgWithError(ctx, request.ID, protocol.InternalError, err.Error())
-		}
+		return client.sendMsgWithError(ctx, request.ID, errorCode(err), err.Error())
 	}
 	return client.sendMsgWithResponse(ctx, request.ID, result)
 }
 
+// errorCode returns the JSON-RPC error code answered for err
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, pkg.ErrMethodNotSupport):
+		return protocol.MethodNotFound
+	case errors.Is(err, pkg.ErrRequestInvalid):
+		return protocol.InvalidRequest
+	case errors.Is(err, pkg.ErrJSONUnmarshal):
+		return protocol.ParseError
+	case errors.Is(err, pkg.ErrSamplingRejected):
+		return protocol.UserRejected
+	default:
+		return protocol.InternalError
+	}
+}
+
 func (client *Client) receiveNotify(ctx context.Context, notify *protocol.JSONRPCNotification) error {
 	switch notify.Method {
 	case protocol.NotificationToolsListChanged:
@@ -110,13 +158,19 @@ func (client *Client) receiveNotify(ctx context.Context, notify *protocol.JSONRP
 		return client.handleNotifyWithResourcesListChanged(ctx, notify.RawParams)
 	case protocol.NotificationResourcesUpdated:
 		return client.handleNotifyWithResourcesUpdated(ctx, notify.RawParams)
+	case protocol.NotificationLogMessage:
+		return client.handleNotifyWithLogMessage(ctx, notify.RawParams)
+	case protocol.NotificationProgress:
+		return client.handleNotifyWithProgress(ctx, notify.RawParams)
+	case protocol.NotificationCancelled:
+		return client.handleNotifyWithCancelled(ctx, notify.RawParams)
 	default:
 		return fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, notify.Method)
 	}
 }
 
 func (client *Client) receiveResponse(response *protocol.JSONRPCResponse) error {
-	respChan, ok := client.reqID2respChan.Get(fmt.Sprint(response.ID))
+	respChan, ok := client.reqID2respChan.Get(protocol.RequestIDKey(response.ID))
 	if !ok {
 		return fmt.Errorf("%w: requestID=%+v", pkg.ErrLackResponseChan, response.ID)
 	}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// RootsProvider
+// Provides the roots answered to the roots/list requests of the server.
+// After the roots of a custom provider change, call Client.SendNotification4RootsListChanged to tell the server.
+type RootsProvider interface {
+	ListRoots(ctx context.Context) ([]protocol.Root, error)
+}
+
+// staticRootsProvider serves the roots given by WithRoots and updated by Client.SetRoots
+type staticRootsProvider struct {
+	mu    sync.RWMutex
+	roots []protocol.Root
+}
+
+func newStaticRootsProvider(roots []protocol.Root) *staticRootsProvider {
+	return &staticRootsProvider{roots: roots}
+}
+
+func (p *staticRootsProvider) ListRoots(_ context.Context) ([]protocol.Root, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]protocol.Root{}, p.roots...), nil
+}
+
+func (p *staticRootsProvider) setRoots(roots []protocol.Root) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.roots = append([]protocol.Root{}, roots...)
+}
+
+// SetRoots replaces the roots given by WithRoots and notifies the server that the roots list has changed
+func (client *Client) SetRoots(ctx context.Context, roots []protocol.Root) error {
+	provider, ok := client.rootsProvider.(*staticRootsProvider)
+	if !ok {
+		if client.rootsProvider == nil {
+			return pkg.ErrClientNotSupport
+		}
+		return errors.New("roots are served by a custom RootsProvider, call SendNotification4RootsListChanged after they change")
+	}
+
+	provider.setRoots(roots)
+	return client.SendNotification4RootsListChanged(ctx)
+}
+
+// SendNotification4RootsListChanged notifies the server that the roots list has changed
+func (client *Client) SendNotification4RootsListChanged(ctx context.Context) error {
+	if client.clientCapabilities.Roots == nil || !client.clientCapabilities.Roots.ListChanged {
+		return pkg.ErrClientNotSupport
+	}
+	return client.sendMsgWithNotification(ctx, protocol.NotificationRootsListChanged, protocol.NewRootsListChangedNotification())
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// SamplingHandler
+// Handles the sampling/createMessage requests of the server, usually by asking the user for approval and then calling the LLM.
+// Return an error wrapping pkg.ErrSamplingRejected when the user rejects the request.
+type SamplingHandler interface {
+	CreateMessage(ctx context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error)
+}
+
+// SamplingHandlerFunc is an adapter to allow the use of ordinary functions as SamplingHandler
+type SamplingHandlerFunc func(ctx context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error)
+
+func (f SamplingHandlerFunc) CreateMessage(ctx context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+	return f(ctx, request)
+}
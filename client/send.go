@@ -38,6 +38,11 @@ func (client *Client) sendMsgWithResponse(ctx context.Context, requestID protoco
 		return err
 	}
 
+	if batch, ok := getBatchResponsesFromCtx(ctx); ok {
+		batch.Add(message)
+		return nil
+	}
+
 	if err := client.transport.Send(ctx, message); err != nil {
 		return fmt.Errorf("sendResponse: transport send: %w", err)
 	}
@@ -70,6 +75,11 @@ func (client *Client) sendMsgWithError(ctx context.Context, requestID protocol.R
 		return err
 	}
 
+	if batch, ok := getBatchResponsesFromCtx(ctx); ok {
+		batch.Add(message)
+		return nil
+	}
+
 	if err := client.transport.Send(ctx, message); err != nil {
 		return fmt.Errorf("sendResponse: transport send: %w", err)
 	}
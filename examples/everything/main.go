@@ -65,14 +65,23 @@ func main() {
 func getTransport() (t transport.ServerTransport) {
 	mode := ""
 	port := ""
-	flag.StringVar(&mode, "transport", "stdio", "The transport to use, should be \"stdio\" or \"sse\"")
-	flag.StringVar(&port, "port", "8080", "sse server address")
+	flag.StringVar(&mode, "transport", "stdio", "The transport to use, should be \"stdio\", \"sse\", \"streamable_http\" or \"websocket\"")
+	flag.StringVar(&port, "port", "8080", "sse, streamable http or websocket server address")
 	flag.Parse()
 
-	if mode == "stdio" {
+	switch mode {
+	case "stdio":
 		log.Println("start current time mcp server with stdio transport")
 		t = transport.NewStdioServerTransport()
-	} else {
+	case "streamable_http":
+		addr := fmt.Sprintf("127.0.0.1:%s", port)
+		log.Printf("start current time mcp server with streamable http transport, listen %s", addr)
+		t, _ = transport.NewStreamableHTTPServerTransport(addr)
+	case "websocket":
+		addr := fmt.Sprintf("127.0.0.1:%s", port)
+		log.Printf("start current time mcp server with websocket transport, listen %s", addr)
+		t, _ = transport.NewWebSocketServerTransport(addr)
+	default:
 		addr := fmt.Sprintf("127.0.0.1:%s", port)
 		log.Printf("start current time mcp server with sse transport, listen %s", addr)
 		t, _ = transport.NewSSEServerTransport(addr)
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"bytes"
+	"sync"
+)
+
+// IsBatch reports whether msg is a JSON-RPC batch, that is a JSON array
+func IsBatch(msg []byte) bool {
+	for _, b := range msg {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// BatchResponses collects the responses to the requests of a JSON-RPC batch, which are sent back together as one array.
+type BatchResponses struct {
+	wg sync.WaitGroup
+
+	mu        sync.Mutex
+	responses [][]byte
+}
+
+// Pending registers a request of the batch being handled, Done must be called once it is
+func (b *BatchResponses) Pending() {
+	b.wg.Add(1)
+}
+
+func (b *BatchResponses) Done() {
+	b.wg.Done()
+}
+
+func (b *BatchResponses) Add(response []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.responses = append(b.responses, response)
+}
+
+// Wait waits for the pending requests and returns the array of their responses,
+// false if there is none to send, as for a batch of notifications.
+func (b *BatchResponses) Wait() ([]byte, bool) {
+	b.wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.responses) == 0 {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	buf.Write(bytes.Join(b.responses, []byte(",")))
+	buf.WriteByte(']')
+	return buf.Bytes(), true
+}
@@ -7,14 +7,19 @@ import (
 
 var (
 	ErrServerNotSupport          = errors.New("this feature server not support")
+	ErrClientNotSupport          = errors.New("this feature client not support")
 	ErrRequestInvalid            = errors.New("request invalid")
+	ErrInvalidParams             = errors.New("invalid params")
 	ErrLackResponseChan          = errors.New("lack response chan")
 	ErrDuplicateResponseReceived = errors.New("duplicate response received")
 	ErrMethodNotSupport          = errors.New("method not support")
 	ErrJSONUnmarshal             = errors.New("json unmarshal error")
 	ErrSessionHasNotInitialized  = errors.New("the session has not been initialized")
 	ErrLackSession               = errors.New("lack session")
+	ErrLackStream                = errors.New("lack stream")
 	ErrSendEOF                   = errors.New("send EOF")
+	ErrSamplingRejected          = errors.New("user rejected sampling request")
+	ErrConnectionLost            = errors.New("connection lost")
 )
 
 type ResponseError struct {
@@ -30,3 +35,30 @@ func NewResponseError(code int, message string, data interface{}) *ResponseError
 func (e *ResponseError) Error() string {
 	return fmt.Sprintf("code=%d message=%s data=%+v", e.Code, e.Message, e.Data)
 }
+
+// ProcessExitError reports the exit of the server process of a stdio transport, it matches ErrConnectionLost with errors.Is
+type ProcessExitError struct {
+	ExitCode int
+	// Stderr is the tail of the standard error of the process
+	Stderr string
+	Err    error
+}
+
+func (e *ProcessExitError) Error() string {
+	msg := fmt.Sprintf("server process exited with code %d", e.ExitCode)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Stderr != "" {
+		msg += ", stderr: " + e.Stderr
+	}
+	return msg
+}
+
+func (e *ProcessExitError) Is(target error) bool {
+	return target == ErrConnectionLost
+}
+
+func (e *ProcessExitError) Unwrap() error {
+	return e.Err
+}
@@ -1,25 +1,105 @@
 package protocol
 
+import (
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
 // CompleteRequest represents a request for completion options
 type CompleteRequest struct {
 	Argument struct {
 		Name  string `json:"name"`
 		Value string `json:"value"`
 	} `json:"argument"`
-	Ref interface{} `json:"ref"` // Can be PromptReference or ResourceReference
+	Ref CompleteReference `json:"ref"` // Can be PromptReference or ResourceReference
+}
+
+func (r *CompleteRequest) UnmarshalJSON(data []byte) error {
+	type alias CompleteRequest
+	temp := &struct {
+		Ref json.RawMessage `json:"ref"`
+		*alias
+	}{
+		alias: (*alias)(r),
+	}
+
+	if err := pkg.JSONUnmarshal(data, temp); err != nil {
+		return err
+	}
+
+	r.Ref = nil
+	if len(temp.Ref) == 0 {
+		return nil
+	}
+
+	// an unknown type leaves Ref nil, the receiver decides how to reject it
+	switch gjson.GetBytes(temp.Ref, "type").String() {
+	case PromptReferenceType:
+		var ref PromptReference
+		if err := pkg.JSONUnmarshal(temp.Ref, &ref); err != nil {
+			return err
+		}
+		r.Ref = ref
+	case ResourceReferenceType:
+		var ref ResourceReference
+		if err := pkg.JSONUnmarshal(temp.Ref, &ref); err != nil {
+			return err
+		}
+		r.Ref = ref
+	}
+	return nil
+}
+
+// CompleteReference is the reference of a completion request, implemented by PromptReference and ResourceReference
+type CompleteReference interface {
+	GetType() string
 }
 
 // Reference types
+const (
+	PromptReferenceType   = "ref/prompt"
+	ResourceReferenceType = "ref/resource"
+)
+
+// PromptReference identifies a prompt
 type PromptReference struct {
 	Type string `json:"type"`
 	Name string `json:"name"`
 }
 
+func (r PromptReference) GetType() string {
+	return PromptReferenceType
+}
+
+// MarshalJSON always writes the type discriminator, even if Type is left empty
+func (r PromptReference) MarshalJSON() ([]byte, error) {
+	type alias PromptReference
+	temp := alias(r)
+	temp.Type = PromptReferenceType
+	return json.Marshal(temp)
+}
+
+// ResourceReference identifies a resource or resource template
 type ResourceReference struct {
 	Type string `json:"type"`
 	URI  string `json:"uri"`
 }
 
+func (r ResourceReference) GetType() string {
+	return ResourceReferenceType
+}
+
+// MarshalJSON always writes the type discriminator, even if Type is left empty
+func (r ResourceReference) MarshalJSON() ([]byte, error) {
+	type alias ResourceReference
+	temp := alias(r)
+	temp.Type = ResourceReferenceType
+	return json.Marshal(temp)
+}
+
 // CompleteResult represents the response to a completion request
 type CompleteResult struct {
 	Completion Complete `json:"completion"`
@@ -31,8 +111,18 @@ type Complete struct {
 	Total   int      `json:"total,omitempty"`
 }
 
+// NewPromptReference creates a reference to the prompt with the given name
+func NewPromptReference(name string) PromptReference {
+	return PromptReference{Type: PromptReferenceType, Name: name}
+}
+
+// NewResourceReference creates a reference to the resource or resource template with the given uri
+func NewResourceReference(uri string) ResourceReference {
+	return ResourceReference{Type: ResourceReferenceType, URI: uri}
+}
+
 // NewCompleteRequest creates a new completion request
-func NewCompleteRequest(argName string, argValue string, ref interface{}) *CompleteRequest {
+func NewCompleteRequest(argName string, argValue string, ref CompleteReference) *CompleteRequest {
 	return &CompleteRequest{
 		Argument: struct {
 			Name  string `json:"name"`
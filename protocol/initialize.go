@@ -24,22 +24,32 @@ type Implementation struct {
 // ClientCapabilities capabilities
 type ClientCapabilities struct {
 	// Experimental map[string]interface{} `json:"experimental,omitempty"`
-	// Roots        *RootsCapability       `json:"roots,omitempty"`
-	// Sampling     interface{}            `json:"sampling,omitempty"`
+	Roots    *RootsCapability    `json:"roots,omitempty"`
+	Sampling *SamplingCapability `json:"sampling,omitempty"`
 }
 
+// SamplingCapability is present if the client supports sampling from an LLM
+type SamplingCapability struct{}
+
 type RootsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
 type ServerCapabilities struct {
 	// Experimental map[string]interface{} `json:"experimental,omitempty"`
-	// Logging      interface{}            `json:"logging,omitempty"`
-	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
-	Resources *ResourcesCapability `json:"resources,omitempty"`
-	Tools     *ToolsCapability     `json:"tools,omitempty"`
+	Completions *CompletionsCapability `json:"completions,omitempty"`
+	Logging     *LoggingCapability     `json:"logging,omitempty"`
+	Prompts     *PromptsCapability     `json:"prompts,omitempty"`
+	Resources   *ResourcesCapability   `json:"resources,omitempty"`
+	Tools       *ToolsCapability       `json:"tools,omitempty"`
 }
 
+// CompletionsCapability present if the server supports argument autocompletion suggestions
+type CompletionsCapability struct{}
+
+// LoggingCapability present if the server supports sending log messages to the client
+type LoggingCapability struct{}
+
 type PromptsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
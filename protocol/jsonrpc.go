@@ -2,6 +2,8 @@ package protocol
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -16,11 +18,31 @@ const (
 	InvalidParams  = -32602 // Invalid method parameter(s)
 	InternalError  = -32603 // Internal JSON-RPC error
 
+	UserRejected = -1 // The user rejected the sampling request
+
 	// 可以定义自己的错误代码，范围在-32000 以上。
 )
 
 type RequestID interface{} // 字符串/数值
 
+// RequestIDKey returns the key of id in the maps of pending requests,
+// the string "1" and the number 1 are different ids while the numbers 1 and 1.0 are the same
+func RequestIDKey(id RequestID) string {
+	switch v := id.(type) {
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return RequestIDKey(f)
+		}
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 type JSONRPCRequest struct {
 	JSONRPC   string          `json:"jsonrpc"`
 	ID        RequestID       `json:"id"`
@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestIDKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b RequestID
+		same bool
+	}{
+		{name: "same_string", a: "1", b: "1", same: true},
+		{name: "string_and_number", a: "1", b: float64(1), same: false},
+		{name: "int_and_float", a: 1, b: float64(1), same: true},
+		{name: "float_forms", a: float64(1), b: json.Number("1.0"), same: true},
+		{name: "different_numbers", a: float64(1), b: float64(2), same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequestIDKey(tt.a) == RequestIDKey(tt.b); got != tt.same {
+				t.Fatalf("RequestIDKey(%#v) == RequestIDKey(%#v): got %v, want %v", tt.a, tt.b, got, tt.same)
+			}
+		})
+	}
+}
@@ -14,6 +14,29 @@ const (
 	LogDebug     LoggingLevel = "debug"
 )
 
+// loggingLevelSeverity follows the syslog ordering defined by RFC 5424, the higher the more severe
+var loggingLevelSeverity = map[LoggingLevel]int{
+	LogDebug:     0,
+	LogInfo:      1,
+	LogNotice:    2,
+	LogWarning:   3,
+	LogError:     4,
+	LogCritical:  5,
+	LogAlert:     6,
+	LogEmergency: 7,
+}
+
+// IsValid reports whether the level is one of the levels defined by the protocol
+func (l LoggingLevel) IsValid() bool {
+	_, ok := loggingLevelSeverity[l]
+	return ok
+}
+
+// Permits reports whether a message of the given level passes when l is the minimum level
+func (l LoggingLevel) Permits(level LoggingLevel) bool {
+	return loggingLevelSeverity[level] >= loggingLevelSeverity[l]
+}
+
 // SetLoggingLevelRequest represents a request to set the logging level
 type SetLoggingLevelRequest struct {
 	Level LoggingLevel `json:"level"`
@@ -26,9 +49,11 @@ type SetLoggingLevelResult struct {
 
 // LogMessageNotification represents a log message notification
 type LogMessageNotification struct {
-	Level   LoggingLevel           `json:"level"`
-	Message string                 `json:"message"`
-	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Level LoggingLevel `json:"level"`
+	// Logger An optional name of the logger issuing this message.
+	Logger string `json:"logger,omitempty"`
+	// Data The data to be logged, such as a string message or an object. Any JSON serializable type is allowed here.
+	Data interface{} `json:"data"`
 }
 
 // NewSetLoggingLevelRequest creates a new set logging level request
@@ -46,10 +71,10 @@ func NewSetLoggingLevelResult(success bool) *SetLoggingLevelResult {
 }
 
 // NewLogMessageNotification creates a new log message notification
-func NewLogMessageNotification(level LoggingLevel, message string, meta map[string]interface{}) *LogMessageNotification {
+func NewLogMessageNotification(level LoggingLevel, logger string, data interface{}) *LogMessageNotification {
 	return &LogMessageNotification{
-		Level:   level,
-		Message: message,
-		Meta:    meta,
+		Level:  level,
+		Logger: logger,
+		Data:   data,
 	}
 }
@@ -10,6 +10,18 @@ type ProgressNotification struct {
 // ProgressToken represents a token used to associate progress notifications with the original request
 type ProgressToken interface{} // can be string or integer
 
+// RequestMeta represents the `_meta` of request params
+type RequestMeta struct {
+	// ProgressToken If specified, the caller is requesting out-of-band progress notifications for this request.
+	ProgressToken ProgressToken `json:"progressToken,omitempty"`
+}
+
+// ProgressReporter reports the progress of a request to the peer that issued it
+type ProgressReporter interface {
+	// Report sends the progress, total is 0 when unknown. Progress must increase with each call.
+	Report(progress float64, total float64) error
+}
+
 // NewProgressNotification creates a new progress notification
 func NewProgressNotification(token ProgressToken, progress float64, total float64) *ProgressNotification {
 	return &ProgressNotification{
@@ -3,12 +3,16 @@ package protocol
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
 // ListPromptsRequest represents a request to list available prompts
-type ListPromptsRequest struct{}
+type ListPromptsRequest struct {
+	// Cursor An opaque token representing the current pagination position, returned as nextCursor by the previous page.
+	Cursor string `json:"cursor,omitempty"`
+}
 
 // ListPromptsResult represents the response to a list prompts request
 type ListPromptsResult struct {
@@ -27,12 +31,17 @@ type PromptArgument struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
 	Required    bool   `json:"required,omitempty"`
+	// Enum candidate values of the argument, not sent to the client but used to answer completion/complete requests
+	Enum []string `json:"-"`
 }
 
 // GetPromptRequest represents a request to get a specific prompt
 type GetPromptRequest struct {
+	Meta      *RequestMeta      `json:"_meta,omitempty"`
 	Name      string            `json:"name"`
 	Arguments map[string]string `json:"arguments,omitempty"`
+	// ProgressReporter is bound by the server when the caller asked for progress, otherwise nil
+	ProgressReporter ProgressReporter `json:"-"`
 }
 
 // GetPromptResult represents the response to a get prompt request
@@ -95,6 +104,39 @@ type PromptListChangedNotification struct {
 	Meta map[string]interface{} `json:"_meta,omitempty"`
 }
 
+// NewPrompt create a prompt, the arguments are generated from the fields of argsStruct,
+// and the `enum` tag of a field becomes the completion candidates of that argument
+func NewPrompt(name string, description string, argsStruct interface{}) (*Prompt, error) {
+	schema, err := generateSchemaFromReqStruct(argsStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, field := range schema.Required {
+		required[field] = true
+	}
+
+	arguments := make([]PromptArgument, 0, len(schema.Properties))
+	for argName, property := range schema.Properties {
+		arguments = append(arguments, PromptArgument{
+			Name:        argName,
+			Description: property.Description,
+			Required:    required[argName],
+			Enum:        property.Enum,
+		})
+	}
+	sort.Slice(arguments, func(i, j int) bool {
+		return arguments[i].Name < arguments[j].Name
+	})
+
+	return &Prompt{
+		Name:        name,
+		Description: description,
+		Arguments:   arguments,
+	}, nil
+}
+
 // NewListPromptsRequest creates a new list prompts request
 func NewListPromptsRequest() *ListPromptsRequest {
 	return &ListPromptsRequest{}
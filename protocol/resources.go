@@ -10,7 +10,10 @@ import (
 )
 
 // ListResourcesRequest Sent from the client to request a list of resources the server has.
-type ListResourcesRequest struct{}
+type ListResourcesRequest struct {
+	// Cursor An opaque token representing the current pagination position, returned as nextCursor by the previous page.
+	Cursor string `json:"cursor,omitempty"`
+}
 
 // ListResourcesResult The server's response to a resources/list request from the client.
 type ListResourcesResult struct {
@@ -23,7 +26,10 @@ type ListResourcesResult struct {
 }
 
 // ListResourceTemplatesRequest represents a request to list resource templates
-type ListResourceTemplatesRequest struct{}
+type ListResourceTemplatesRequest struct {
+	// Cursor An opaque token representing the current pagination position, returned as nextCursor by the previous page.
+	Cursor string `json:"cursor,omitempty"`
+}
 
 // ListResourceTemplatesResult represents the response to a list resource templates request
 type ListResourceTemplatesResult struct {
@@ -33,8 +39,11 @@ type ListResourceTemplatesResult struct {
 
 // ReadResourceRequest represents a request to read a specific resource
 type ReadResourceRequest struct {
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
 	URI       string                 `json:"uri"`
 	Arguments map[string]interface{} `json:"-"`
+	// ProgressReporter is bound by the server when the caller asked for progress, otherwise nil
+	ProgressReporter ProgressReporter `json:"-"`
 }
 
 // ReadResourceResult The server's response to a resources/read request from the client.
@@ -100,6 +109,8 @@ type ResourceTemplate struct {
 	URITemplateParsed *uritemplate.Template `json:"-"`
 	Description       string                `json:"description,omitempty"`
 	MimeType          string                `json:"mimeType,omitempty"`
+	// VariableEnums candidate values of the template variables, not sent to the client but used to answer completion/complete requests
+	VariableEnums map[string][]string `json:"-"`
 }
 
 func (t *ResourceTemplate) UnmarshalJSON(data []byte) error {
@@ -1,5 +1,14 @@
 package protocol
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
 // CreateMessageRequest represents a request to create a message through sampling
 type CreateMessageRequest struct {
 	Messages         []SamplingMessage      `json:"messages"`
@@ -17,6 +26,27 @@ type SamplingMessage struct {
 	Content Content `json:"content"`
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface for SamplingMessage
+func (m *SamplingMessage) UnmarshalJSON(data []byte) error {
+	type Alias SamplingMessage
+	aux := &struct {
+		Content json.RawMessage `json:"content"`
+		*Alias
+	}{
+		Alias: (*Alias)(m),
+	}
+	if err := pkg.JSONUnmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	content, err := unmarshalSamplingContent(aux.Content)
+	if err != nil {
+		return err
+	}
+	m.Content = content
+	return nil
+}
+
 // CreateMessageResult represents the response to a create message request
 type CreateMessageResult struct {
 	Content    Content `json:"content"`
@@ -25,6 +55,53 @@ type CreateMessageResult struct {
 	StopReason string  `json:"stopReason,omitempty"`
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface for CreateMessageResult
+func (r *CreateMessageResult) UnmarshalJSON(data []byte) error {
+	type Alias CreateMessageResult
+	aux := &struct {
+		Content json.RawMessage `json:"content"`
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	}
+	if err := pkg.JSONUnmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	content, err := unmarshalSamplingContent(aux.Content)
+	if err != nil {
+		return err
+	}
+	r.Content = content
+	return nil
+}
+
+// unmarshalSamplingContent decodes the content of a sampling message, which is text, image or audio
+func unmarshalSamplingContent(data json.RawMessage) (Content, error) {
+	switch contentType := gjson.GetBytes(data, "type").String(); contentType {
+	case "text":
+		var content TextContent
+		if err := pkg.JSONUnmarshal(data, &content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	case "image":
+		var content ImageContent
+		if err := pkg.JSONUnmarshal(data, &content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	case "audio":
+		var content AudioContent
+		if err := pkg.JSONUnmarshal(data, &content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	default:
+		return nil, fmt.Errorf("unknown sampling content type %q", contentType)
+	}
+}
+
 // NewCreateMessageRequest creates a new create message request
 func NewCreateMessageRequest(messages []SamplingMessage, maxTokens int, opts ...CreateMessageOption) *CreateMessageRequest {
 	req := &CreateMessageRequest{
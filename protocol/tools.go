@@ -8,7 +8,10 @@ import (
 )
 
 // ListToolsRequest represents a request to list available tools
-type ListToolsRequest struct{}
+type ListToolsRequest struct {
+	// Cursor An opaque token representing the current pagination position, returned as nextCursor by the previous page.
+	Cursor string `json:"cursor,omitempty"`
+}
 
 // ListToolsResult represents the response to a list tools request
 type ListToolsResult struct {
@@ -65,9 +68,12 @@ type InputSchema struct {
 
 // CallToolRequest represents a request to call a specific tool
 type CallToolRequest struct {
+	Meta         *RequestMeta           `json:"_meta,omitempty"`
 	Name         string                 `json:"name"`
 	Arguments    map[string]interface{} `json:"arguments,omitempty"`
 	RawArguments json.RawMessage        `json:"-"`
+	// ProgressReporter is bound by the server when the caller asked for progress, otherwise nil
+	ProgressReporter ProgressReporter `json:"-"`
 }
 
 func (r *CallToolRequest) UnmarshalJSON(data []byte) error {
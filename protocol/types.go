@@ -1,6 +1,44 @@
 package protocol
 
-const Version = "2024-11-05"
+// Protocol versions, see https://modelcontextprotocol.io/specification/versioning
+const (
+	Version20241105 = "2024-11-05"
+	Version20250326 = "2025-03-26"
+)
+
+// Version is the latest protocol version supported
+const Version = Version20250326
+
+// SupportedVersions lists the protocol versions supported by default, latest first
+var SupportedVersions = []string{Version20250326, Version20241105}
+
+// NegotiateVersion returns the version answered to a peer requesting the given version:
+// the requested version if it is supported, otherwise the latest supported one.
+// supported is ordered latest first.
+func NegotiateVersion(requested string, supported []string) string {
+	for _, version := range supported {
+		if version == requested {
+			return version
+		}
+	}
+	return supported[0]
+}
+
+// IsBatchSupported reports whether JSON-RPC batches may be sent with version,
+// batching was added in 2025-03-26 and is removed by the following revision.
+func IsBatchSupported(version string) bool {
+	return version == Version20250326
+}
+
+// IsVersionSupported reports whether version is in supported
+func IsVersionSupported(version string, supported []string) bool {
+	for _, v := range supported {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
 
 // Method represents the JSON-RPC method name
 type Method string
@@ -46,7 +84,7 @@ const (
 
 	// progress related methods
 	NotificationProgress  Method = "notifications/progress"
-	NotificationCancelled Method = "notifications/canceled"
+	NotificationCancelled Method = "notifications/cancelled"
 )
 
 // Role represents the sender or recipient of messages and data in a conversation
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
@@ -29,6 +30,72 @@ func (server *Server) Ping(ctx context.Context, request *protocol.PingRequest) (
 	return &result, nil
 }
 
+// ListRoots returns the roots of the client of the session carried by ctx, the client must have declared the roots capability.
+// The roots are cached in the session when the client sends notifications/roots/list_changed on change,
+// otherwise they are listed from the client on every call.
+func (server *Server) ListRoots(ctx context.Context) (*protocol.ListRootsResult, error) {
+	sessionID, err := getSessionIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+	capabilities := s.GetClientCapabilities()
+	if capabilities == nil || capabilities.Roots == nil {
+		return nil, pkg.ErrClientNotSupport
+	}
+
+	if roots, ok := s.GetRoots(); ok && capabilities.Roots.ListChanged {
+		return protocol.NewListRootsResult(roots), nil
+	}
+	return server.listRoots(ctx, sessionID, s)
+}
+
+func (server *Server) listRoots(ctx context.Context, sessionID string, s *session.State) (*protocol.ListRootsResult, error) {
+	response, err := server.callClient(ctx, sessionID, protocol.RootsList, protocol.NewListRootsRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.ListRootsResult
+	if err := pkg.JSONUnmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	s.SetRoots(result.Roots)
+	return &result, nil
+}
+
+// CreateMessage asks the client of the session carried by ctx to sample its LLM,
+// the client must have declared the sampling capability.
+func (server *Server) CreateMessage(ctx context.Context, request *protocol.CreateMessageRequest) (*protocol.CreateMessageResult, error) {
+	sessionID, err := getSessionIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+	if capabilities := s.GetClientCapabilities(); capabilities == nil || capabilities.Sampling == nil {
+		return nil, pkg.ErrClientNotSupport
+	}
+
+	response, err := server.callClient(ctx, sessionID, protocol.SamplingCreateMessage, request)
+	if err != nil {
+		return nil, err
+	}
+
+	var result protocol.CreateMessageResult
+	if err := pkg.JSONUnmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &result, nil
+}
+
 func (server *Server) sendNotification4ToolListChanges(ctx context.Context) error {
 	if server.capabilities.Tools == nil || !server.capabilities.Tools.ListChanged {
 		return pkg.ErrServerNotSupport
@@ -94,6 +161,62 @@ func (server *Server) SendNotification4ResourcesUpdated(ctx context.Context, not
 	return pkg.JoinErrors(errList)
 }
 
+// Log sends a notifications/message to the session carried by ctx, or to every session when ctx carries none.
+// Sessions whose logging level, set through logging/setLevel, is above the given level are skipped.
+func (server *Server) Log(ctx context.Context, level protocol.LoggingLevel, logger string, data interface{}) error {
+	if server.capabilities.Logging == nil {
+		return pkg.ErrServerNotSupport
+	}
+
+	if !level.IsValid() {
+		return fmt.Errorf("unknown logging level %q", level)
+	}
+
+	notify := protocol.NewLogMessageNotification(level, logger, data)
+
+	if sessionID, err := getSessionIDFromCtx(ctx); err == nil {
+		s, ok := server.sessionManager.GetSession(sessionID)
+		if !ok {
+			return pkg.ErrLackSession
+		}
+		if minLevel := s.GetLoggingLevel(); minLevel != "" && !minLevel.Permits(level) {
+			return nil
+		}
+		return server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationLogMessage, notify)
+	}
+
+	var errList []error
+	server.sessionManager.RangeSessions(func(sessionID string, s *session.State) bool {
+		if minLevel := s.GetLoggingLevel(); minLevel != "" && !minLevel.Permits(level) {
+			return true
+		}
+
+		if err := server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationLogMessage, notify); err != nil {
+			errList = append(errList, fmt.Errorf("sessionID=%s, err: %w", sessionID, err))
+		}
+		return true
+	})
+	return pkg.JoinErrors(errList)
+}
+
+func (server *Server) sendNotification4Cancelled(ctx context.Context, sessionID string, requestID protocol.RequestID, reason string) error {
+	return server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationCancelled, protocol.NewCancelledNotification(requestID, reason))
+}
+
+// cancelRequest tells the client to stop handling the request, the caller has already stopped waiting for it.
+func (server *Server) cancelRequest(sessionID string, requestID protocol.RequestID, reason string) {
+	go func() {
+		defer pkg.Recover()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := server.sendNotification4Cancelled(ctx, sessionID, requestID, reason); err != nil {
+			server.logger.Warnf("send cancelled notification fail: sessionID=%s, requestID=%v, err=%v", sessionID, requestID, err)
+		}
+	}()
+}
+
 // Responsible for request and response assembly
 func (server *Server) callClient(ctx context.Context, sessionID string, method protocol.Method, params protocol.ServerRequest) (json.RawMessage, error) {
 	session, ok := server.sessionManager.GetSession(sessionID)
@@ -103,8 +226,8 @@ func (server *Server) callClient(ctx context.Context, sessionID string, method p
 
 	requestID := strconv.FormatInt(session.IncRequestID(), 10)
 	respChan := make(chan *protocol.JSONRPCResponse, 1)
-	session.GetReqID2respChan().Set(requestID, respChan)
-	defer session.GetReqID2respChan().Remove(requestID)
+	session.GetReqID2respChan().Set(protocol.RequestIDKey(requestID), respChan)
+	defer session.GetReqID2respChan().Remove(protocol.RequestIDKey(requestID))
 
 	if err := server.sendMsgWithRequest(ctx, sessionID, requestID, method, params); err != nil {
 		return nil, err
@@ -112,6 +235,7 @@ func (server *Server) callClient(ctx context.Context, sessionID string, method p
 
 	select {
 	case <-ctx.Done():
+		server.cancelRequest(sessionID, requestID, ctx.Err().Error())
 		return nil, ctx.Err()
 	case response := <-respChan:
 		if err := response.Error; err != nil {
@@ -3,6 +3,9 @@ package server
 import (
 	"context"
 	"errors"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 )
 
 type sessionIDKey struct{}
@@ -18,3 +21,80 @@ func getSessionIDFromCtx(ctx context.Context) (string, error) {
 	}
 	return sessionID.(string), nil
 }
+
+// GetSessionIDFromCtx returns the id of the session the handled request comes from
+func GetSessionIDFromCtx(ctx context.Context) (string, error) {
+	return getSessionIDFromCtx(ctx)
+}
+
+type requestIDKey struct{}
+
+func setRequestIDToCtx(ctx context.Context, requestID protocol.RequestID) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// GetRequestIDFromCtx returns the JSON-RPC id of the handled request
+func GetRequestIDFromCtx(ctx context.Context) (protocol.RequestID, error) {
+	requestID := ctx.Value(requestIDKey{})
+	if requestID == nil {
+		return nil, errors.New("no request id found")
+	}
+	return requestID, nil
+}
+
+type clientInfoKey struct{}
+
+func setClientInfoToCtx(ctx context.Context, clientInfo *protocol.Implementation) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, clientInfo)
+}
+
+// GetClientInfoFromCtx returns the implementation the client declared in its initialize request
+func GetClientInfoFromCtx(ctx context.Context) (*protocol.Implementation, error) {
+	clientInfo, _ := ctx.Value(clientInfoKey{}).(*protocol.Implementation)
+	if clientInfo == nil {
+		return nil, errors.New("no client info found")
+	}
+	return clientInfo, nil
+}
+
+type clientCapabilitiesKey struct{}
+
+func setClientCapabilitiesToCtx(ctx context.Context, capabilities *protocol.ClientCapabilities) context.Context {
+	return context.WithValue(ctx, clientCapabilitiesKey{}, capabilities)
+}
+
+// GetClientCapabilitiesFromCtx returns the capabilities the client declared in its initialize request
+func GetClientCapabilitiesFromCtx(ctx context.Context) (*protocol.ClientCapabilities, error) {
+	capabilities, _ := ctx.Value(clientCapabilitiesKey{}).(*protocol.ClientCapabilities)
+	if capabilities == nil {
+		return nil, errors.New("no client capabilities found")
+	}
+	return capabilities, nil
+}
+
+type protocolVersionKey struct{}
+
+func setProtocolVersionToCtx(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, protocolVersionKey{}, version)
+}
+
+// GetProtocolVersionFromCtx returns the protocol version negotiated with the client, handlers can branch on it
+func GetProtocolVersionFromCtx(ctx context.Context) (string, error) {
+	version, _ := ctx.Value(protocolVersionKey{}).(string)
+	if version == "" {
+		return "", errors.New("no protocol version found")
+	}
+	return version, nil
+}
+
+type batchResponsesKey struct{}
+
+// setBatchResponsesToCtx makes the response of the request handled with ctx part of the batch responses
+func setBatchResponsesToCtx(ctx context.Context, batch *pkg.BatchResponses) context.Context {
+	return context.WithValue(ctx, batchResponsesKey{}, batch)
+}
+
+func getBatchResponsesFromCtx(ctx context.Context) (*pkg.BatchResponses, bool) {
+	batch, ok := ctx.Value(batchResponsesKey{}).(*pkg.BatchResponses)
+	return batch, ok
+}
@@ -1,8 +1,11 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/yosida95/uritemplate/v3"
 
@@ -20,21 +23,22 @@ func (server *Server) handleRequestWithInitialize(sessionID string, rawParams js
 		return nil, err
 	}
 
-	if request.ProtocolVersion != protocol.Version {
-		return nil, fmt.Errorf("protocol version not supported, supported version is %v", protocol.Version)
-	}
-
 	s, ok := server.sessionManager.GetSession(sessionID)
 	if !ok {
 		return nil, pkg.ErrLackSession
 	}
+
+	// an unsupported version is answered with the latest supported one, the client decides whether to disconnect
+	protocolVersion := protocol.NegotiateVersion(request.ProtocolVersion, server.supportedVersions)
+
 	s.SetClientInfo(&request.ClientInfo, &request.Capabilities)
+	s.SetProtocolVersion(protocolVersion)
 	s.SetReceivedInitRequest()
 
 	return &protocol.InitializeResult{
 		ServerInfo:      *server.serverInfo,
 		Capabilities:    *server.capabilities,
-		ProtocolVersion: protocol.Version,
+		ProtocolVersion: protocolVersion,
 		Instructions:    server.instructions,
 	}, nil
 }
@@ -44,9 +48,9 @@ func (server *Server) handleRequestWithListPrompts(rawParams json.RawMessage) (*
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	var request *protocol.ListPromptsRequest
+	request := &protocol.ListPromptsRequest{}
 	if len(rawParams) > 0 {
-		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
 			return nil, err
 		}
 	}
@@ -57,12 +61,19 @@ func (server *Server) handleRequestWithListPrompts(rawParams json.RawMessage) (*
 		return true
 	})
 
+	prompts, nextCursor, err := paginate(prompts, func(prompt protocol.Prompt) string { return prompt.Name },
+		"prompts", request.Cursor, server.pageSize)
+	if err != nil {
+		return nil, err
+	}
+
 	return &protocol.ListPromptsResult{
-		Prompts: prompts,
+		Prompts:    prompts,
+		NextCursor: nextCursor,
 	}, nil
 }
 
-func (server *Server) handleRequestWithGetPrompt(rawParams json.RawMessage) (*protocol.GetPromptResult, error) {
+func (server *Server) handleRequestWithGetPrompt(ctx context.Context, sessionID string, rawParams json.RawMessage) (*protocol.GetPromptResult, error) {
 	if server.capabilities.Prompts == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
@@ -76,7 +87,8 @@ func (server *Server) handleRequestWithGetPrompt(rawParams json.RawMessage) (*pr
 	if !ok {
 		return nil, fmt.Errorf("missing prompt, promptName=%s", request.Name)
 	}
-	return entry.handler(request)
+	request.ProgressReporter = server.newProgressReporter(sessionID, request.Meta)
+	return entry.handler(ctx, request)
 }
 
 func (server *Server) handleRequestWithListResources(rawParams json.RawMessage) (*protocol.ListResourcesResult, error) {
@@ -84,9 +96,9 @@ func (server *Server) handleRequestWithListResources(rawParams json.RawMessage)
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	var request *protocol.ListResourcesRequest
+	request := &protocol.ListResourcesRequest{}
 	if len(rawParams) > 0 {
-		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
 			return nil, err
 		}
 	}
@@ -97,8 +109,15 @@ func (server *Server) handleRequestWithListResources(rawParams json.RawMessage)
 		return true
 	})
 
+	resources, nextCursor, err := paginate(resources, func(resource protocol.Resource) string { return resource.URI },
+		"resources", request.Cursor, server.pageSize)
+	if err != nil {
+		return nil, err
+	}
+
 	return &protocol.ListResourcesResult{
-		Resources: resources,
+		Resources:  resources,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -107,9 +126,9 @@ func (server *Server) handleRequestWithListResourceTemplates(rawParams json.RawM
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	var request *protocol.ListResourceTemplatesRequest
+	request := &protocol.ListResourceTemplatesRequest{}
 	if len(rawParams) > 0 {
-		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
 			return nil, err
 		}
 	}
@@ -120,12 +139,19 @@ func (server *Server) handleRequestWithListResourceTemplates(rawParams json.RawM
 		return true
 	})
 
+	templates, nextCursor, err := paginate(templates, func(template protocol.ResourceTemplate) string { return template.URITemplate },
+		"resourceTemplates", request.Cursor, server.pageSize)
+	if err != nil {
+		return nil, err
+	}
+
 	return &protocol.ListResourceTemplatesResult{
 		ResourceTemplates: templates,
+		NextCursor:        nextCursor,
 	}, nil
 }
 
-func (server *Server) handleRequestWithReadResource(rawParams json.RawMessage) (*protocol.ReadResourceResult, error) {
+func (server *Server) handleRequestWithReadResource(ctx context.Context, sessionID string, rawParams json.RawMessage) (*protocol.ReadResourceResult, error) {
 	if server.capabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
@@ -135,7 +161,7 @@ func (server *Server) handleRequestWithReadResource(rawParams json.RawMessage) (
 		return nil, err
 	}
 
-	var handler ResourceHandlerFunc
+	var handler ResourceHandlerFuncWithCtx
 	if entry, ok := server.resources.Load(request.URI); ok {
 		handler = entry.handler
 	}
@@ -156,7 +182,8 @@ func (server *Server) handleRequestWithReadResource(rawParams json.RawMessage) (
 	if handler == nil {
 		return nil, fmt.Errorf("missing resource, resourceName=%s", request.URI)
 	}
-	return handler(request)
+	request.ProgressReporter = server.newProgressReporter(sessionID, request.Meta)
+	return handler(ctx, request)
 }
 
 func matchesTemplate(uri string, template *uritemplate.Template) bool {
@@ -206,7 +233,7 @@ func (server *Server) handleRequestWithListTools(rawParams json.RawMessage) (*pr
 
 	request := &protocol.ListToolsRequest{}
 	if len(rawParams) > 0 {
-		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
 			return nil, err
 		}
 	}
@@ -217,10 +244,16 @@ func (server *Server) handleRequestWithListTools(rawParams json.RawMessage) (*pr
 		return true
 	})
 
-	return &protocol.ListToolsResult{Tools: tools}, nil
+	tools, nextCursor, err := paginate(tools, func(tool *protocol.Tool) string { return tool.Name },
+		"tools", request.Cursor, server.pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.ListToolsResult{Tools: tools, NextCursor: nextCursor}, nil
 }
 
-func (server *Server) handleRequestWithCallTool(rawParams json.RawMessage) (*protocol.CallToolResult, error) {
+func (server *Server) handleRequestWithCallTool(ctx context.Context, sessionID string, rawParams json.RawMessage) (*protocol.CallToolResult, error) {
 	if server.capabilities.Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
@@ -234,8 +267,108 @@ func (server *Server) handleRequestWithCallTool(rawParams json.RawMessage) (*pro
 	if !ok {
 		return nil, fmt.Errorf("missing tool, toolName=%s", request.Name)
 	}
+	request.ProgressReporter = server.newProgressReporter(sessionID, request.Meta)
 
-	return entry.handler(request)
+	return entry.handler(ctx, request)
+}
+
+func (server *Server) handleRequestWithSetLogLevel(sessionID string, rawParams json.RawMessage) (*protocol.SetLoggingLevelResult, error) {
+	if server.capabilities.Logging == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	var request *protocol.SetLoggingLevelRequest
+	if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		return nil, err
+	}
+
+	if !request.Level.IsValid() {
+		return nil, fmt.Errorf("%w: unknown logging level %q", pkg.ErrInvalidParams, request.Level)
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+	s.SetLoggingLevel(request.Level)
+	return protocol.NewSetLoggingLevelResult(true), nil
+}
+
+// maxCompletionValues the maximum number of values in a completion response defined by the protocol
+const maxCompletionValues = 100
+
+func (server *Server) handleRequestWithComplete(ctx context.Context, rawParams json.RawMessage) (*protocol.CompleteResult, error) {
+	if server.capabilities.Completions == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	request := &protocol.CompleteRequest{}
+	if err := pkg.JSONUnmarshal(rawParams, request); err != nil {
+		return nil, err
+	}
+
+	var (
+		handler    CompletionHandlerFunc
+		candidates []string
+	)
+
+	switch ref := request.Ref.(type) {
+	case protocol.PromptReference:
+		entry, ok := server.prompts.Load(ref.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: missing prompt, promptName=%s", pkg.ErrInvalidParams, ref.Name)
+		}
+		handler, _ = server.promptCompletions.Load(completionKey(ref.Name, request.Argument.Name))
+		for _, argument := range entry.prompt.Arguments {
+			if argument.Name == request.Argument.Name {
+				candidates = argument.Enum
+				break
+			}
+		}
+	case protocol.ResourceReference:
+		entry, ok := server.resourceTemplates.Load(ref.URI)
+		if !ok {
+			return nil, fmt.Errorf("%w: missing resource template, uriTemplate=%s", pkg.ErrInvalidParams, ref.URI)
+		}
+		handler, _ = server.resourceTemplateCompletions.Load(completionKey(ref.URI, request.Argument.Name))
+		candidates = entry.resourceTemplate.VariableEnums[request.Argument.Name]
+	default:
+		return nil, fmt.Errorf("%w: missing or unknown completion ref", pkg.ErrInvalidParams)
+	}
+
+	if handler == nil {
+		return completeFromCandidates(candidates, request.Argument.Value), nil
+	}
+
+	result, err := handler(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if result.Completion.Values == nil {
+		result.Completion.Values = make([]string, 0)
+	}
+	if len(result.Completion.Values) > maxCompletionValues {
+		if result.Completion.Total == 0 {
+			result.Completion.Total = len(result.Completion.Values)
+		}
+		result.Completion.Values = result.Completion.Values[:maxCompletionValues]
+		result.Completion.HasMore = true
+	}
+	return result, nil
+}
+
+func completeFromCandidates(candidates []string, value string) *protocol.CompleteResult {
+	values := make([]string, 0)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, value) {
+			values = append(values, candidate)
+		}
+	}
+
+	if total := len(values); total > maxCompletionValues {
+		return protocol.NewCompleteResult(values[:maxCompletionValues], true, total)
+	}
+	return protocol.NewCompleteResult(values, false, len(values))
 }
 
 func (server *Server) handleNotifyWithInitialized(sessionID string, rawParams json.RawMessage) error {
@@ -257,3 +390,49 @@ func (server *Server) handleNotifyWithInitialized(sessionID string, rawParams js
 	s.SetReady()
 	return nil
 }
+
+func (server *Server) handleNotifyWithCancelled(sessionID string, rawParams json.RawMessage) error {
+	param := &protocol.CancelledNotification{}
+	if err := pkg.JSONUnmarshal(rawParams, param); err != nil {
+		return err
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return pkg.ErrLackSession
+	}
+
+	// the request may already be finished, in which case the notification is ignored
+	if cancel, ok := s.GetReqID2cancel().Get(protocol.RequestIDKey(param.RequestID)); ok {
+		server.logger.Debugf("cancel request: sessionID=%s, requestID=%v, reason=%s", sessionID, param.RequestID, param.Reason)
+		(*cancel)()
+	}
+	return nil
+}
+
+func (server *Server) handleNotifyWithRootsListChanged(sessionID string, rawParams json.RawMessage) error {
+	param := &protocol.RootsListChangedNotification{}
+	if len(rawParams) > 0 {
+		if err := pkg.JSONUnmarshal(rawParams, param); err != nil {
+			return err
+		}
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return pkg.ErrLackSession
+	}
+
+	ctx, cancel := context.WithTimeout(setSessionIDToCtx(context.Background(), sessionID), 10*time.Second)
+	defer cancel()
+
+	result, err := server.listRoots(ctx, sessionID, s)
+	if err != nil {
+		return fmt.Errorf("refresh roots fail: %w", err)
+	}
+
+	if server.rootsChangedHandler != nil {
+		server.rootsChangedHandler(ctx, result.Roots)
+	}
+	return nil
+}
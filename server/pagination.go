@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+const cursorSeparator = "\x00"
+
+// encodeCursor builds an opaque cursor pointing after the item identified by key.
+// Since it records a key instead of an offset, registering or unregistering items between two pages neither skips nor repeats items.
+func encodeCursor(kind string, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + cursorSeparator + key))
+}
+
+func decodeCursor(kind string, cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid cursor %q", pkg.ErrInvalidParams, cursor)
+	}
+	cursorKind, key, found := strings.Cut(string(b), cursorSeparator)
+	if !found || cursorKind != kind {
+		return "", fmt.Errorf("%w: invalid cursor %q", pkg.ErrInvalidParams, cursor)
+	}
+	return key, nil
+}
+
+// paginate sorts items by key and returns the page following cursor, pageSize <= 0 means no pagination.
+func paginate[T any](items []T, key func(T) string, kind string, cursor string, pageSize int) ([]T, string, error) {
+	sort.Slice(items, func(i, j int) bool {
+		return key(items[i]) < key(items[j])
+	})
+
+	if cursor != "" {
+		after, err := decodeCursor(kind, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start := sort.Search(len(items), func(i int) bool {
+			return key(items[i]) > after
+		})
+		items = items[start:]
+	}
+
+	if pageSize <= 0 || len(items) <= pageSize {
+		return items, "", nil
+	}
+	items = items[:pageSize]
+	return items, encodeCursor(kind, key(items[len(items)-1])), nil
+}
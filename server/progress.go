@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// progressReporter sends notifications/progress of one request to the session that issued it.
+// Reports arriving faster than the interval are dropped, except the final one, so that a tight loop cannot flood the session.
+type progressReporter struct {
+	server    *Server
+	sessionID string
+	token     protocol.ProgressToken
+	interval  time.Duration
+
+	mu           sync.Mutex
+	lastSentAt   time.Time
+	lastProgress float64
+	reported     bool
+}
+
+func (server *Server) newProgressReporter(sessionID string, meta *protocol.RequestMeta) protocol.ProgressReporter {
+	if meta == nil || meta.ProgressToken == nil {
+		return nil
+	}
+	return &progressReporter{
+		server:    server,
+		sessionID: sessionID,
+		token:     meta.ProgressToken,
+		interval:  server.progressInterval,
+	}
+}
+
+func (r *progressReporter) Report(progress float64, total float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.reported && progress <= r.lastProgress {
+		return fmt.Errorf("progress must increase, last=%v, current=%v", r.lastProgress, progress)
+	}
+
+	final := total > 0 && progress >= total
+	if r.reported && !final && time.Since(r.lastSentAt) < r.interval {
+		return nil
+	}
+
+	if err := r.server.sendMsgWithNotification(context.Background(), r.sessionID, protocol.NotificationProgress,
+		protocol.NewProgressNotification(r.token, progress, total)); err != nil {
+		return err
+	}
+
+	r.reported = true
+	r.lastProgress = progress
+	r.lastSentAt = time.Now()
+	return nil
+}
@@ -2,13 +2,16 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/tidwall/gjson"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
 func (server *Server) receive(_ context.Context, sessionID string, msg []byte) error {
@@ -16,6 +19,87 @@ func (server *Server) receive(_ context.Context, sessionID string, msg []byte) e
 		return pkg.ErrLackSession
 	}
 
+	if pkg.IsBatch(msg) {
+		return server.receiveBatch(sessionID, msg)
+	}
+	return server.receiveMessage(sessionID, msg, nil)
+}
+
+// receiveBatch dispatches the members of a batch concurrently, the responses to its requests are sent back as one array
+func (server *Server) receiveBatch(sessionID string, msg []byte) error {
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return pkg.ErrLackSession
+	}
+	if version := s.GetProtocolVersion(); !protocol.IsBatchSupported(version) {
+		return server.rejectBatch(sessionID, fmt.Errorf("%w: batch not supported by protocol version %q", pkg.ErrRequestInvalid, version))
+	}
+
+	members := gjson.ParseBytes(msg).Array()
+	if len(members) == 0 {
+		return server.rejectBatch(sessionID, fmt.Errorf("%w: empty batch", pkg.ErrRequestInvalid))
+	}
+
+	// the array of responses is waited for on shutdown, like the responses of single requests
+	server.inFlyRequest.Add(1)
+
+	batch := &pkg.BatchResponses{}
+	for _, member := range members {
+		// a member that is neither a request, a notification nor a response can't be identified, its error has a null id
+		if !member.IsObject() || (!member.Get("method").Exists() && !member.Get("id").Exists()) {
+			resp, _ := json.Marshal(protocol.NewJSONRPCErrorResponse(nil, protocol.InvalidRequest, "invalid batch member"))
+			batch.Add(resp)
+			continue
+		}
+
+		if err := server.receiveMessage(sessionID, []byte(member.Raw), batch); err != nil {
+			server.logger.Errorf("receive batch member:%s error: %s", member.Get("method").String(), err.Error())
+
+			// the requests that can not be handled are answered in the batch as well
+			if id := member.Get("id"); id.Exists() && member.Get("method").Exists() {
+				resp, _ := json.Marshal(protocol.NewJSONRPCErrorResponse(json.RawMessage(id.Raw), errorCode(err), err.Error()))
+				batch.Add(resp)
+			}
+		}
+	}
+
+	go func() {
+		defer pkg.Recover()
+		defer server.inFlyRequest.Done()
+
+		message, ok := batch.Wait()
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := server.transport.Send(ctx, sessionID, message); err != nil {
+			server.logger.Errorf("send batch response error: %s", err.Error())
+		}
+	}()
+	return nil
+}
+
+// rejectBatch answers a batch that can't be handled at all with a single error response with a null id, and returns err
+func (server *Server) rejectBatch(sessionID string, err error) error {
+	message, marshalErr := json.Marshal(protocol.NewJSONRPCErrorResponse(nil, errorCode(err), err.Error()))
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if sendErr := server.transport.Send(ctx, sessionID, message); sendErr != nil {
+		server.logger.Errorf("send batch error response error: %s", sendErr.Error())
+	}
+	return err
+}
+
+// receiveMessage handles a single message, the response of a request is added to batch when it is a member of one
+func (server *Server) receiveMessage(sessionID string, msg []byte, batch *pkg.BatchResponses) error {
 	if !gjson.GetBytes(msg, "id").Exists() {
 		notify := &protocol.JSONRPCNotification{}
 		if err := pkg.JSONUnmarshal(msg, &notify); err != nil {
@@ -71,11 +155,38 @@ func (server *Server) receive(_ context.Context, sessionID string, msg []byte) e
 		return errors.New("server already shutdown")
 	}
 
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		defer server.inFlyRequest.Done()
+		return pkg.ErrLackSession
+	}
+
+	// the client may send notifications/cancelled right after the request, which is handled concurrently,
+	// so the request is cancellable before being dispatched. Initialize must not be cancelled, it is never registered.
+	ctx, cancel := context.WithCancel(context.Background())
+	reqKey := protocol.RequestIDKey(req.ID)
+	if req.Method != protocol.Initialize {
+		s.GetReqID2cancel().Set(reqKey, &cancel)
+	}
+
+	if batch != nil {
+		ctx = setBatchResponsesToCtx(ctx, batch)
+		batch.Pending()
+	}
+
 	go func() {
 		defer pkg.Recover()
 		defer server.inFlyRequest.Done()
+		if batch != nil {
+			defer batch.Done()
+		}
+		// the id may be reused once the request is answered, so only the entry of this request is removed
+		defer s.GetReqID2cancel().RemoveCb(reqKey, func(_ string, v *context.CancelFunc, exists bool) bool {
+			return exists && v == &cancel
+		})
+		defer cancel()
 
-		if err := server.receiveRequest(sessionID, req); err != nil {
+		if err := server.receiveRequest(ctx, sessionID, req); err != nil {
 			req.RawParams = nil // simplified log
 			server.logger.Errorf("receive request:%+v error: %s", req, err.Error())
 			return
@@ -85,13 +196,26 @@ func (server *Server) receive(_ context.Context, sessionID string, msg []byte) e
 	return nil
 }
 
-func (server *Server) receiveRequest(sessionID string, request *protocol.JSONRPCRequest) error {
-	if request.Method != protocol.Initialize && request.Method != protocol.Ping {
-		if s, ok := server.sessionManager.GetSession(sessionID); !ok {
-			return pkg.ErrLackSession
-		} else if !s.GetReady() {
-			return pkg.ErrSessionHasNotInitialized
-		}
+func (server *Server) receiveRequest(ctx context.Context, sessionID string, request *protocol.JSONRPCRequest) error {
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return pkg.ErrLackSession
+	}
+	if request.Method != protocol.Initialize && request.Method != protocol.Ping && !s.GetReady() {
+		return pkg.ErrSessionHasNotInitialized
+	}
+
+	ctx = setSessionIDToCtx(ctx, sessionID)
+	ctx = setRequestIDToCtx(ctx, request.ID)
+	ctx = transport.WithRelatedRequestID(ctx, request.ID)
+	if clientInfo := s.GetClientInfo(); clientInfo != nil {
+		ctx = setClientInfoToCtx(ctx, clientInfo)
+	}
+	if clientCapabilities := s.GetClientCapabilities(); clientCapabilities != nil {
+		ctx = setClientCapabilitiesToCtx(ctx, clientCapabilities)
+	}
+	if protocolVersion := s.GetProtocolVersion(); protocolVersion != "" {
+		ctx = setProtocolVersionToCtx(ctx, protocolVersion)
 	}
 
 	if request.Method != protocol.Ping {
@@ -111,13 +235,13 @@ func (server *Server) receiveRequest(sessionID string, request *protocol.JSONRPC
 	case protocol.PromptsList:
 		result, err = server.handleRequestWithListPrompts(request.RawParams)
 	case protocol.PromptsGet:
-		result, err = server.handleRequestWithGetPrompt(request.RawParams)
+		result, err = server.handleRequestWithGetPrompt(ctx, sessionID, request.RawParams)
 	case protocol.ResourcesList:
 		result, err = server.handleRequestWithListResources(request.RawParams)
 	case protocol.ResourceListTemplates:
 		result, err = server.handleRequestWithListResourceTemplates(request.RawParams)
 	case protocol.ResourcesRead:
-		result, err = server.handleRequestWithReadResource(request.RawParams)
+		result, err = server.handleRequestWithReadResource(ctx, sessionID, request.RawParams)
 	case protocol.ResourcesSubscribe:
 		result, err = server.handleRequestWithSubscribeResourceChange(sessionID, request.RawParams)
 	case protocol.ResourcesUnsubscribe:
@@ -125,28 +249,43 @@ func (server *Server) receiveRequest(sessionID string, request *protocol.JSONRPC
 	case protocol.ToolsList:
 		result, err = server.handleRequestWithListTools(request.RawParams)
 	case protocol.ToolsCall:
-		result, err = server.handleRequestWithCallTool(request.RawParams)
+		result, err = server.handleRequestWithCallTool(ctx, sessionID, request.RawParams)
+	case protocol.CompletionComplete:
+		result, err = server.handleRequestWithComplete(ctx, request.RawParams)
+	case protocol.LoggingSetLevel:
+		result, err = server.handleRequestWithSetLogLevel(sessionID, request.RawParams)
 	default:
 		err = fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, request.Method)
 	}
 
-	ctx := context.Background()
+	if ctx.Err() != nil {
+		// the request has been cancelled by the client, which no longer expects a response
+		server.logger.Debugf("request cancelled, response suppressed: sessionID=%s, requestID=%v", sessionID, request.ID)
+		return nil
+	}
 
 	if err != nil {
-		switch {
-		case errors.Is(err, pkg.ErrMethodNotSupport):
-			return server.sendMsgWithError(ctx, sessionID, request.ID, protocol.MethodNotFound, err.Error())
-		case errors.Is(err, pkg.ErrRequestInvalid):
-			return server.sendMsgWithError(ctx, sessionID, request.ID, protocol.InvalidRequest, err.Error())
-		case errors.Is(err, pkg.ErrJSONUnmarshal):
-			return server.sendMsgWithError(ctx, sessionID, request.ID, protocol.ParseError, err.Error())
-		default:
-			return server.sendMsgWithError(ctx, sessionID, request.ID, protocol.InternalError, err.Error())
-		}
+		return server.sendMsgWithError(ctx, sessionID, request.ID, errorCode(err), err.Error())
 	}
 	return server.sendMsgWithResponse(ctx, sessionID, request.ID, result)
 }
 
+// errorCode returns the JSON-RPC error code answered for err
+func errorCode(err error) int {
+	switch {
+	case errors.Is(err, pkg.ErrMethodNotSupport):
+		return protocol.MethodNotFound
+	case errors.Is(err, pkg.ErrRequestInvalid):
+		return protocol.InvalidRequest
+	case errors.Is(err, pkg.ErrInvalidParams):
+		return protocol.InvalidParams
+	case errors.Is(err, pkg.ErrJSONUnmarshal):
+		return protocol.ParseError
+	default:
+		return protocol.InternalError
+	}
+}
+
 func (server *Server) receiveNotify(sessionID string, notify *protocol.JSONRPCNotification) error {
 	if s, ok := server.sessionManager.GetSession(sessionID); !ok {
 		return pkg.ErrLackSession
@@ -157,6 +296,10 @@ func (server *Server) receiveNotify(sessionID string, notify *protocol.JSONRPCNo
 	switch notify.Method {
 	case protocol.NotificationInitialized:
 		return server.handleNotifyWithInitialized(sessionID, notify.RawParams)
+	case protocol.NotificationCancelled:
+		return server.handleNotifyWithCancelled(sessionID, notify.RawParams)
+	case protocol.NotificationRootsListChanged:
+		return server.handleNotifyWithRootsListChanged(sessionID, notify.RawParams)
 	default:
 		return fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, notify.Method)
 	}
@@ -168,7 +311,7 @@ func (server *Server) receiveResponse(sessionID string, response *protocol.JSONR
 		return pkg.ErrLackSession
 	}
 
-	respChan, ok := s.GetReqID2respChan().Get(fmt.Sprint(response.ID))
+	respChan, ok := s.GetReqID2respChan().Get(protocol.RequestIDKey(response.ID))
 	if !ok {
 		return fmt.Errorf("%w: sessionID=%+v, requestID=%+v", pkg.ErrLackResponseChan, sessionID, response.ID)
 	}
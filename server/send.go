@@ -36,6 +36,11 @@ func (server *Server) sendMsgWithResponse(ctx context.Context, sessionID string,
 		return err
 	}
 
+	if batch, ok := getBatchResponsesFromCtx(ctx); ok {
+		batch.Add(message)
+		return nil
+	}
+
 	if err := server.transport.Send(ctx, sessionID, message); err != nil {
 		return fmt.Errorf("sendResponse: transport send: %w", err)
 	}
@@ -68,6 +73,11 @@ func (server *Server) sendMsgWithError(ctx context.Context, sessionID string, re
 		return err
 	}
 
+	if batch, ok := getBatchResponsesFromCtx(ctx); ok {
+		batch.Add(message)
+		return nil
+	}
+
 	if err := server.transport.Send(ctx, sessionID, message); err != nil {
 		return fmt.Errorf("sendResponse: transport send: %w", err)
 	}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -38,6 +39,38 @@ func WithSessionMaxIdleTime(maxIdleTime time.Duration) Option {
 	}
 }
 
+// WithProgressInterval sets the minimum interval between two progress notifications of the same request,
+// intermediate reports arriving faster are dropped.
+func WithProgressInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.progressInterval = interval
+	}
+}
+
+// WithPageSize sets the maximum number of items returned by one page of tools/list, prompts/list,
+// resources/list and resources/templates/list, 0 means everything is returned in one page.
+func WithPageSize(pageSize int) Option {
+	return func(s *Server) {
+		s.pageSize = pageSize
+	}
+}
+
+// WithSupportedVersions sets the protocol versions the server supports, latest first.
+// A client requesting any other version is answered with the first one.
+func WithSupportedVersions(versions ...string) Option {
+	return func(s *Server) {
+		s.supportedVersions = versions
+	}
+}
+
+// WithRootsChangedHandler sets the callback fired after the roots of a session have been refreshed
+// in response to notifications/roots/list_changed.
+func WithRootsChangedHandler(handler RootsChangedHandlerFunc) Option {
+	return func(s *Server) {
+		s.rootsChangedHandler = handler
+	}
+}
+
 func WithLogger(logger pkg.Logger) Option {
 	return func(s *Server) {
 		s.logger = logger
@@ -52,14 +85,23 @@ type Server struct {
 	resources         pkg.SyncMap[*resourceEntry]
 	resourceTemplates pkg.SyncMap[*resourceTemplateEntry]
 
+	promptCompletions           pkg.SyncMap[CompletionHandlerFunc]
+	resourceTemplateCompletions pkg.SyncMap[CompletionHandlerFunc]
+
 	sessionManager *session.Manager
 
 	inShutdown   *pkg.AtomicBool // true when server is in shutdown
 	inFlyRequest sync.WaitGroup
 
-	capabilities *protocol.ServerCapabilities
-	serverInfo   *protocol.Implementation
-	instructions string
+	capabilities      *protocol.ServerCapabilities
+	serverInfo        *protocol.Implementation
+	instructions      string
+	supportedVersions []string
+
+	progressInterval time.Duration
+	pageSize         int
+
+	rootsChangedHandler RootsChangedHandlerFunc
 
 	logger pkg.Logger
 }
@@ -68,13 +110,17 @@ func NewServer(t transport.ServerTransport, opts ...Option) (*Server, error) {
 	server := &Server{
 		transport: t,
 		capabilities: &protocol.ServerCapabilities{
-			Prompts:   &protocol.PromptsCapability{ListChanged: true},
-			Resources: &protocol.ResourcesCapability{ListChanged: true, Subscribe: true},
-			Tools:     &protocol.ToolsCapability{ListChanged: true},
+			Completions: &protocol.CompletionsCapability{},
+			Logging:     &protocol.LoggingCapability{},
+			Prompts:     &protocol.PromptsCapability{ListChanged: true},
+			Resources:   &protocol.ResourcesCapability{ListChanged: true, Subscribe: true},
+			Tools:       &protocol.ToolsCapability{ListChanged: true},
 		},
-		inShutdown: pkg.NewAtomicBool(),
-		serverInfo: &protocol.Implementation{},
-		logger:     pkg.DefaultLogger,
+		inShutdown:        pkg.NewAtomicBool(),
+		serverInfo:        &protocol.Implementation{},
+		supportedVersions: protocol.SupportedVersions,
+		progressInterval:  100 * time.Millisecond,
+		logger:            pkg.DefaultLogger,
 	}
 
 	t.SetReceiver(transport.ServerReceiverF(server.receive))
@@ -85,7 +131,14 @@ func NewServer(t transport.ServerTransport, opts ...Option) (*Server, error) {
 		opt(server)
 	}
 
+	if len(server.supportedVersions) == 0 {
+		return nil, errors.New("supported protocol versions can't be empty")
+	}
+
 	t.SetSessionManager(server.sessionManager)
+	if setter, ok := t.(transport.SupportedVersionsSetter); ok {
+		setter.SetSupportedVersions(server.supportedVersions)
+	}
 
 	return server, nil
 }
@@ -103,14 +156,31 @@ func (server *Server) Run() error {
 	return nil
 }
 
+// RootsChangedHandlerFunc is called with the refreshed roots of the session carried by ctx
+type RootsChangedHandlerFunc func(ctx context.Context, roots []protocol.Root)
+
 type toolEntry struct {
 	tool    *protocol.Tool
-	handler ToolHandlerFunc
+	handler ToolHandlerFuncWithCtx
 }
 
 type ToolHandlerFunc func(*protocol.CallToolRequest) (*protocol.CallToolResult, error)
 
+// ToolHandlerFuncWithCtx is the context-aware form of ToolHandlerFunc, see GetSessionIDFromCtx and the other accessors for what ctx carries.
+// ctx is canceled when the client cancels the request.
+type ToolHandlerFuncWithCtx func(ctx context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error)
+
+func (f ToolHandlerFunc) withCtx() ToolHandlerFuncWithCtx {
+	return func(_ context.Context, request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		return f(request)
+	}
+}
+
 func (server *Server) RegisterTool(tool *protocol.Tool, toolHandler ToolHandlerFunc) {
+	server.RegisterToolWithCtx(tool, toolHandler.withCtx())
+}
+
+func (server *Server) RegisterToolWithCtx(tool *protocol.Tool, toolHandler ToolHandlerFuncWithCtx) {
 	server.tools.Store(tool.Name, &toolEntry{tool: tool, handler: toolHandler})
 	if !server.sessionManager.IsEmpty() {
 		if err := server.sendNotification4ToolListChanges(context.Background()); err != nil {
@@ -132,12 +202,25 @@ func (server *Server) UnregisterTool(name string) {
 
 type promptEntry struct {
 	prompt  *protocol.Prompt
-	handler PromptHandlerFunc
+	handler PromptHandlerFuncWithCtx
 }
 
 type PromptHandlerFunc func(*protocol.GetPromptRequest) (*protocol.GetPromptResult, error)
 
+// PromptHandlerFuncWithCtx is the context-aware form of PromptHandlerFunc
+type PromptHandlerFuncWithCtx func(ctx context.Context, request *protocol.GetPromptRequest) (*protocol.GetPromptResult, error)
+
+func (f PromptHandlerFunc) withCtx() PromptHandlerFuncWithCtx {
+	return func(_ context.Context, request *protocol.GetPromptRequest) (*protocol.GetPromptResult, error) {
+		return f(request)
+	}
+}
+
 func (server *Server) RegisterPrompt(prompt *protocol.Prompt, promptHandler PromptHandlerFunc) {
+	server.RegisterPromptWithCtx(prompt, promptHandler.withCtx())
+}
+
+func (server *Server) RegisterPromptWithCtx(prompt *protocol.Prompt, promptHandler PromptHandlerFuncWithCtx) {
 	server.prompts.Store(prompt.Name, &promptEntry{prompt: prompt, handler: promptHandler})
 	if !server.sessionManager.IsEmpty() {
 		if err := server.sendNotification4PromptListChanges(context.Background()); err != nil {
@@ -159,12 +242,25 @@ func (server *Server) UnregisterPrompt(name string) {
 
 type resourceEntry struct {
 	resource *protocol.Resource
-	handler  ResourceHandlerFunc
+	handler  ResourceHandlerFuncWithCtx
 }
 
 type ResourceHandlerFunc func(*protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error)
 
+// ResourceHandlerFuncWithCtx is the context-aware form of ResourceHandlerFunc
+type ResourceHandlerFuncWithCtx func(ctx context.Context, request *protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error)
+
+func (f ResourceHandlerFunc) withCtx() ResourceHandlerFuncWithCtx {
+	return func(_ context.Context, request *protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error) {
+		return f(request)
+	}
+}
+
 func (server *Server) RegisterResource(resource *protocol.Resource, resourceHandler ResourceHandlerFunc) {
+	server.RegisterResourceWithCtx(resource, resourceHandler.withCtx())
+}
+
+func (server *Server) RegisterResourceWithCtx(resource *protocol.Resource, resourceHandler ResourceHandlerFuncWithCtx) {
 	server.resources.Store(resource.URI, &resourceEntry{resource: resource, handler: resourceHandler})
 	if !server.sessionManager.IsEmpty() {
 		if err := server.sendNotification4ResourceListChanges(context.Background()); err != nil {
@@ -186,10 +282,14 @@ func (server *Server) UnregisterResource(uri string) {
 
 type resourceTemplateEntry struct {
 	resourceTemplate *protocol.ResourceTemplate
-	handler          ResourceHandlerFunc
+	handler          ResourceHandlerFuncWithCtx
 }
 
 func (server *Server) RegisterResourceTemplate(resource *protocol.ResourceTemplate, resourceHandler ResourceHandlerFunc) error {
+	return server.RegisterResourceTemplateWithCtx(resource, resourceHandler.withCtx())
+}
+
+func (server *Server) RegisterResourceTemplateWithCtx(resource *protocol.ResourceTemplate, resourceHandler ResourceHandlerFuncWithCtx) error {
 	if err := resource.ParseURITemplate(); err != nil {
 		return err
 	}
@@ -213,6 +313,26 @@ func (server *Server) UnregisterResourceTemplate(uriTemplate string) {
 	}
 }
 
+// CompletionHandlerFunc returns the completion candidates of one argument,
+// request.Argument.Value is the partial value the user has typed so far.
+type CompletionHandlerFunc func(ctx context.Context, request *protocol.CompleteRequest) (*protocol.CompleteResult, error)
+
+// RegisterPromptCompletion registers the completion of the argument argName of the prompt promptName.
+// Without it, the candidates fall back to PromptArgument.Enum.
+func (server *Server) RegisterPromptCompletion(promptName string, argName string, handler CompletionHandlerFunc) {
+	server.promptCompletions.Store(completionKey(promptName, argName), handler)
+}
+
+// RegisterResourceTemplateCompletion registers the completion of the variable varName of the resource template uriTemplate.
+// Without it, the candidates fall back to ResourceTemplate.VariableEnums.
+func (server *Server) RegisterResourceTemplateCompletion(uriTemplate string, varName string, handler CompletionHandlerFunc) {
+	server.resourceTemplateCompletions.Store(completionKey(uriTemplate, varName), handler)
+}
+
+func completionKey(ref string, argName string) string {
+	return ref + "#" + argName
+}
+
 func (server *Server) Shutdown(userCtx context.Context) error {
 	server.inShutdown.Store(true)
 
@@ -240,6 +360,10 @@ func (server *Server) sessionDetection(ctx context.Context, sessionID string) er
 	defer cancel()
 
 	if _, err := server.Ping(setSessionIDToCtx(ctx, sessionID), protocol.NewPingRequest()); err != nil {
+		// the client has no open stream to ping it on, which does not mean the session is gone
+		if errors.Is(err, pkg.ErrLackStream) {
+			return nil
+		}
 		return err
 	}
 	return nil
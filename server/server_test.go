@@ -2,15 +2,20 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server/session"
 	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
@@ -78,6 +83,7 @@ func TestServerHandle(t *testing.T) {
 				Name:        "params1",
 				Description: "params1's description",
 				Required:    true,
+				Enum:        []string{"alpha", "beta", "alpine"},
 			},
 		},
 	}
@@ -122,6 +128,10 @@ func TestServerHandle(t *testing.T) {
 		t.Fatalf("RegisterResourceTemplate: %+v", err)
 		return
 	}
+	server.RegisterResourceTemplateCompletion(testResourceTemplate.URITemplate, "path",
+		func(_ context.Context, request *protocol.CompleteRequest) (*protocol.CompleteResult, error) {
+			return protocol.NewCompleteResult([]string{request.Argument.Value + ".txt"}, false, 1), nil
+		})
 
 	go func() {
 		if err := server.Run(); err != nil {
@@ -219,6 +229,28 @@ func TestServerHandle(t *testing.T) {
 			},
 			expectedResponse: protocol.UnsubscribeResult{},
 		},
+		{
+			name:   "test_set_logging_level",
+			method: protocol.LoggingSetLevel,
+			request: protocol.SetLoggingLevelRequest{
+				Level: protocol.LogWarning,
+			},
+			expectedResponse: protocol.SetLoggingLevelResult{Success: true},
+		},
+		{
+			name:   "test_complete_prompt_argument",
+			method: protocol.CompletionComplete,
+			request: protocol.NewCompleteRequest("params1", "al",
+				protocol.NewPromptReference(testPrompt.Name)),
+			expectedResponse: protocol.NewCompleteResult([]string{"alpha", "alpine"}, false, 2),
+		},
+		{
+			name:   "test_complete_resource_template_variable",
+			method: protocol.CompletionComplete,
+			request: protocol.NewCompleteRequest("path", "test",
+				protocol.NewResourceReference(testResourceTemplate.URITemplate)),
+			expectedResponse: protocol.NewCompleteResult([]string{"test.txt"}, false, 1),
+		},
 	}
 
 	for _, tt := range tests {
@@ -410,6 +442,17 @@ func TestServerNotify(t *testing.T) {
 			},
 			expectedNotify: protocol.NewResourceListChangedNotification(),
 		},
+		{
+			name:   "test_log_message_notify",
+			method: protocol.NotificationLogMessage,
+			f: func() {
+				if err := server.Log(context.Background(), protocol.LogInfo, "test_logger", "test log"); err != nil {
+					t.Errorf("Log: %+v", err)
+					return
+				}
+			},
+			expectedNotify: protocol.NewLogMessageNotification(protocol.LogInfo, "test_logger", "test log"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -455,8 +498,16 @@ func TestServerNotify(t *testing.T) {
 }
 
 func testServerInit(t *testing.T, server *Server, in io.Writer, outScan *bufio.Scanner) {
+	testServerInitWithCapabilities(t, server, in, outScan, protocol.ClientCapabilities{})
+}
+
+func testServerInitWithCapabilities(t *testing.T, server *Server, in io.Writer, outScan *bufio.Scanner, capabilities protocol.ClientCapabilities) {
+	testServerInitWithVersion(t, server, in, outScan, protocol.Version, capabilities)
+}
+
+func testServerInitWithVersion(t *testing.T, server *Server, in io.Writer, outScan *bufio.Scanner, version string, capabilities protocol.ClientCapabilities) {
 	uuid, _ := uuid.NewUUID()
-	req := protocol.NewJSONRPCRequest(uuid, protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: protocol.Version})
+	req := protocol.NewJSONRPCRequest(uuid, protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: version, Capabilities: capabilities})
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		t.Fatalf("json Marshal: %+v", err)
@@ -479,7 +530,7 @@ func testServerInit(t *testing.T, server *Server, in io.Writer, outScan *bufio.S
 	}
 
 	expectedResp := protocol.NewJSONRPCSuccessResponse(uuid, protocol.InitializeResult{
-		ProtocolVersion: protocol.Version,
+		ProtocolVersion: version,
 		Capabilities:    *server.capabilities,
 		ServerInfo:      *server.serverInfo,
 	})
@@ -505,3 +556,564 @@ func testServerInit(t *testing.T, server *Server, in io.Writer, outScan *bufio.S
 		t.Fatalf("in Write: %+v", err)
 	}
 }
+
+// testServerConn is the client end of a server over a mock transport,
+// what is written to in is received by the server and the server's messages are read line by line from out
+type testServerConn struct {
+	t   *testing.T
+	in  io.Writer
+	out *bufio.Scanner
+}
+
+func newTestServer(t *testing.T, opts ...Option) (*Server, *testServerConn) {
+	t.Helper()
+
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	server, err := NewServer(transport.NewMockServerTransport(reader1, writer2), opts...)
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	return server, &testServerConn{t: t, in: writer1, out: bufio.NewScanner(reader2)}
+}
+
+func runTestServer(t *testing.T, server *Server) {
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+}
+
+func (c *testServerConn) write(v interface{}) {
+	c.t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		c.t.Fatalf("json Marshal: %+v", err)
+	}
+	c.writeRaw(b)
+}
+
+func (c *testServerConn) writeRaw(b []byte) {
+	c.t.Helper()
+
+	if _, err := c.in.Write(append(b, "\n"...)); err != nil {
+		c.t.Fatalf("in Write: %+v", err)
+	}
+}
+
+func (c *testServerConn) read() []byte {
+	c.t.Helper()
+
+	if !c.out.Scan() {
+		c.t.Fatalf("outScan: %+v", c.out.Err())
+	}
+	return c.out.Bytes()
+}
+
+func (c *testServerConn) readResponse() *protocol.JSONRPCResponse {
+	c.t.Helper()
+
+	resp := &protocol.JSONRPCResponse{}
+	if err := pkg.JSONUnmarshal(c.read(), resp); err != nil {
+		c.t.Fatal(err)
+	}
+	return resp
+}
+
+func (c *testServerConn) readRequest() *protocol.JSONRPCRequest {
+	c.t.Helper()
+
+	req := &protocol.JSONRPCRequest{}
+	if err := pkg.JSONUnmarshal(c.read(), req); err != nil {
+		c.t.Fatal(err)
+	}
+	return req
+}
+
+func TestServerProgress(t *testing.T) {
+	server, conn := newTestServer(t, WithProgressInterval(time.Hour))
+
+	testTool, err := protocol.NewTool("test_tool", "test_tool", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	server.RegisterTool(testTool, func(request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		for _, progress := range []float64{1, 1.5, 2} {
+			if err := request.ProgressReporter.Report(progress, 2); err != nil {
+				return nil, err
+			}
+		}
+		return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+	})
+
+	runTestServer(t, server)
+	testServerInit(t, server, conn.in, conn.out)
+
+	request := protocol.NewCallToolRequest(testTool.Name, nil)
+	request.Meta = &protocol.RequestMeta{ProgressToken: "test_token"}
+	conn.write(protocol.NewJSONRPCRequest(1, protocol.ToolsCall, request))
+
+	// the intermediate report 1.5 is dropped by the rate limit, the final one is always sent
+	for _, expectedProgress := range []float64{1, 2} {
+		notifyBytes := conn.read()
+		notify := &protocol.JSONRPCNotification{}
+		if err = pkg.JSONUnmarshal(notifyBytes, &notify); err != nil {
+			t.Fatal(err)
+		}
+		var progress protocol.ProgressNotification
+		if err = pkg.JSONUnmarshal(notify.RawParams, &progress); err != nil {
+			t.Fatal(err)
+		}
+		expected := protocol.ProgressNotification{ProgressToken: "test_token", Progress: expectedProgress, Total: 2}
+		if notify.Method != protocol.NotificationProgress || !reflect.DeepEqual(progress, expected) {
+			t.Fatalf("notify not as expected.\ngot  = %s\nwant = %+v", notifyBytes, expected)
+		}
+	}
+
+	if resp := conn.readResponse(); resp.Error != nil {
+		t.Fatalf("call tool error: %+v", resp.Error)
+	}
+}
+
+func TestServerCancelRequest(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	testTool, err := protocol.NewTool("test_tool", "test_tool", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	handlerDone := make(chan error, 1)
+	server.RegisterToolWithCtx(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		if sessionID, err := GetSessionIDFromCtx(ctx); err != nil || sessionID == "" {
+			handlerDone <- fmt.Errorf("session id not in ctx: %v", err)
+			return nil, err
+		}
+		if requestID, err := GetRequestIDFromCtx(ctx); err != nil || requestID != "call" {
+			handlerDone <- fmt.Errorf("request id not as expected: %v, %v", requestID, err)
+			return nil, err
+		}
+		<-ctx.Done()
+		handlerDone <- nil
+		return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+	})
+
+	runTestServer(t, server)
+	testServerInit(t, server, conn.in, conn.out)
+
+	conn.write(protocol.NewJSONRPCRequest("call", protocol.ToolsCall, protocol.NewCallToolRequest(testTool.Name, nil)))
+	conn.write(protocol.NewJSONRPCNotification(protocol.NotificationCancelled, protocol.NewCancelledNotification("call", "test")))
+	if err = <-handlerDone; err != nil {
+		t.Fatal(err)
+	}
+
+	// the response of the cancelled call must be suppressed, so the next message is the ping response
+	conn.write(protocol.NewJSONRPCRequest("ping", protocol.Ping, protocol.NewPingRequest()))
+	if resp := conn.readResponse(); resp.ID != "ping" {
+		t.Fatalf("response not as expected.\ngot  = %+v\nwant ping response", resp)
+	}
+}
+
+func TestServerCancelRequestIDType(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	testTool, err := protocol.NewTool("test_tool", "test_tool", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	handlerDone := make(chan error, 1)
+	server.RegisterToolWithCtx(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		select {
+		case <-ctx.Done():
+			handlerDone <- errors.New("call cancelled by the cancellation of another request id")
+		case <-time.After(200 * time.Millisecond):
+			handlerDone <- nil
+		}
+		return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+	})
+
+	runTestServer(t, server)
+	testServerInit(t, server, conn.in, conn.out)
+
+	// the string id "1" is not the same request as the numeric id 1
+	conn.write(protocol.NewJSONRPCRequest(1, protocol.ToolsCall, protocol.NewCallToolRequest(testTool.Name, nil)))
+	conn.write(protocol.NewJSONRPCNotification(protocol.NotificationCancelled, protocol.NewCancelledNotification("1", "test")))
+	if err = <-handlerDone; err != nil {
+		t.Fatal(err)
+	}
+	if resp := conn.readResponse(); resp.Error != nil {
+		t.Fatalf("call tool error: %+v", resp.Error)
+	}
+}
+
+func TestServerCallClientCancel(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	testTool, err := protocol.NewTool("list_roots", "list_roots", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	handlerDone := make(chan error, 1)
+	server.RegisterToolWithCtx(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		_, err := server.ListRoots(ctx)
+		handlerDone <- err
+		return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+	})
+
+	runTestServer(t, server)
+	testServerInitWithCapabilities(t, server, conn.in, conn.out,
+		protocol.ClientCapabilities{Roots: &protocol.RootsCapability{}})
+
+	conn.write(protocol.NewJSONRPCRequest("call", protocol.ToolsCall, protocol.NewCallToolRequest(testTool.Name, nil)))
+	req := conn.readRequest()
+	if req.Method != protocol.RootsList {
+		t.Fatalf("request method not as expected.\ngot  = %s\nwant = %s", req.Method, protocol.RootsList)
+	}
+
+	// the roots/list request is left unanswered, so the server gives up on it when the ctx of ListRoots is done.
+	// The cancellation is sent concurrently with the tool call response, in any order.
+	var notify struct {
+		Method protocol.Method                `json:"method"`
+		Params protocol.CancelledNotification `json:"params"`
+	}
+	for i := 0; i < 2 && notify.Method == ""; i++ {
+		if err = pkg.JSONUnmarshal(conn.read(), &notify); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if notify.Method != protocol.NotificationCancelled || !reflect.DeepEqual(notify.Params.RequestID, req.ID) {
+		t.Fatalf("notification not as expected.\ngot  = %+v\nwant cancellation of %v", notify, req.ID)
+	}
+	if err = <-handlerDone; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ListRoots error not as expected.\ngot  = %v\nwant = %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	runTestServer(t, server)
+	testServerInitWithVersion(t, server, conn.in, conn.out, protocol.Version20250326, protocol.ClientCapabilities{})
+
+	conn.write([]interface{}{
+		protocol.NewJSONRPCRequest("ping", protocol.Ping, protocol.NewPingRequest()),
+		protocol.NewJSONRPCNotification(protocol.NotificationCancelled, protocol.NewCancelledNotification("unknown", "test")),
+		protocol.NewJSONRPCRequest("tools", protocol.ToolsList, protocol.NewListToolsRequest()),
+		map[string]interface{}{"id": "invalid", "method": protocol.Ping},
+		1,
+	})
+
+	respBytes := conn.read()
+	var resps []*protocol.JSONRPCResponse
+	if err := pkg.JSONUnmarshal(respBytes, &resps); err != nil {
+		t.Fatal(err)
+	}
+	if len(resps) != 4 {
+		t.Fatalf("batch response not as expected.\ngot  = %s\nwant 4 responses", respBytes)
+	}
+
+	codes := map[protocol.RequestID]int{}
+	for _, resp := range resps {
+		codes[resp.ID] = 0
+		if resp.Error != nil {
+			codes[resp.ID] = resp.Error.Code
+		}
+	}
+	expected := map[protocol.RequestID]int{"ping": 0, "tools": 0, "invalid": protocol.InvalidRequest, nil: protocol.InvalidRequest}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Fatalf("batch response not as expected.\ngot  = %s\nwant error codes %v", respBytes, expected)
+	}
+
+	// an empty batch is answered with a single error
+	conn.writeRaw([]byte(`[]`))
+	if resp := conn.readResponse(); resp.ID != nil || resp.Error == nil || resp.Error.Code != protocol.InvalidRequest {
+		t.Fatalf("response not as expected.\ngot  = %+v\nwant invalid request error", resp)
+	}
+}
+
+func TestServerBatchNotSupported(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	runTestServer(t, server)
+	testServerInitWithVersion(t, server, conn.in, conn.out, protocol.Version20241105, protocol.ClientCapabilities{})
+
+	// batching was added after the protocol version negotiated
+	conn.write([]interface{}{protocol.NewJSONRPCRequest("ping", protocol.Ping, protocol.NewPingRequest())})
+	if resp := conn.readResponse(); resp.ID != nil || resp.Error == nil || resp.Error.Code != protocol.InvalidRequest {
+		t.Fatalf("response not as expected.\ngot  = %+v\nwant invalid request error", resp)
+	}
+}
+
+func TestServerCreateMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities protocol.ClientCapabilities
+		expectedErr  error
+	}{
+		{
+			name:         "test_client_support_sampling",
+			capabilities: protocol.ClientCapabilities{Sampling: &protocol.SamplingCapability{}},
+		},
+		{
+			name:         "test_client_not_support_sampling",
+			capabilities: protocol.ClientCapabilities{},
+			expectedErr:  pkg.ErrClientNotSupport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestServer(t)
+
+			expectedResult := protocol.NewCreateMessageResult(protocol.TextContent{Type: "text", Text: "summary"}, protocol.RoleAssistant, "test-model", "endTurn")
+
+			testTool, err := protocol.NewTool("summarize", "summarize", currentTimeReq{})
+			if err != nil {
+				t.Fatalf("NewTool: %+v", err)
+			}
+			handlerDone := make(chan error, 1)
+			server.RegisterToolWithCtx(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+				result, err := server.CreateMessage(ctx, protocol.NewCreateMessageRequest([]protocol.SamplingMessage{
+					{Role: protocol.RoleUser, Content: protocol.TextContent{Type: "text", Text: "summarize it"}},
+				}, 100))
+				if err != nil {
+					if !errors.Is(err, tt.expectedErr) {
+						handlerDone <- fmt.Errorf("CreateMessage error not as expected.\ngot  = %v\nwant = %v", err, tt.expectedErr)
+					} else {
+						handlerDone <- nil
+					}
+					return nil, err
+				}
+				if !reflect.DeepEqual(result, expectedResult) {
+					handlerDone <- fmt.Errorf("CreateMessage result not as expected.\ngot  = %+v\nwant = %+v", result, expectedResult)
+				} else {
+					handlerDone <- nil
+				}
+				return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+			})
+
+			runTestServer(t, server)
+			testServerInitWithCapabilities(t, server, conn.in, conn.out, tt.capabilities)
+
+			conn.write(protocol.NewJSONRPCRequest("call", protocol.ToolsCall, protocol.NewCallToolRequest(testTool.Name, nil)))
+
+			if tt.expectedErr == nil {
+				req := conn.readRequest()
+				if req.Method != protocol.SamplingCreateMessage {
+					t.Fatalf("request method not as expected.\ngot  = %s\nwant = %s", req.Method, protocol.SamplingCreateMessage)
+				}
+				conn.write(protocol.NewJSONRPCSuccessResponse(req.ID, expectedResult))
+			}
+
+			if err = <-handlerDone; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestServerRoots(t *testing.T) {
+	rootsChanged := make(chan []protocol.Root, 1)
+	server, conn := newTestServer(t, WithRootsChangedHandler(func(_ context.Context, roots []protocol.Root) {
+		rootsChanged <- roots
+	}))
+
+	testTool, err := protocol.NewTool("list_roots", "list_roots", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	handlerDone := make(chan []protocol.Root, 1)
+	server.RegisterToolWithCtx(testTool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		var roots []protocol.Root
+		// the second call is answered from the session cache without asking the client again
+		for i := 0; i < 2; i++ {
+			result, err := server.ListRoots(ctx)
+			if err != nil {
+				t.Errorf("ListRoots: %+v", err)
+				return nil, err
+			}
+			roots = result.Roots
+		}
+		handlerDone <- roots
+		return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+	})
+
+	runTestServer(t, server)
+	testServerInitWithCapabilities(t, server, conn.in, conn.out,
+		protocol.ClientCapabilities{Roots: &protocol.RootsCapability{ListChanged: true}})
+
+	answerListRoots := func(roots []protocol.Root) {
+		req := conn.readRequest()
+		if req.Method != protocol.RootsList {
+			t.Fatalf("request method not as expected.\ngot  = %s\nwant = %s", req.Method, protocol.RootsList)
+		}
+		conn.write(protocol.NewJSONRPCSuccessResponse(req.ID, protocol.NewListRootsResult(roots)))
+	}
+
+	roots := []protocol.Root{{Name: "workspace", URI: "file:///workspace"}}
+	conn.write(protocol.NewJSONRPCRequest("call", protocol.ToolsCall, protocol.NewCallToolRequest(testTool.Name, nil)))
+	answerListRoots(roots)
+	if got := <-handlerDone; !reflect.DeepEqual(got, roots) {
+		t.Fatalf("roots not as expected.\ngot  = %+v\nwant = %+v", got, roots)
+	}
+	conn.read() // tool call response
+
+	newRoots := []protocol.Root{{Name: "other", URI: "file:///other"}}
+	conn.write(protocol.NewJSONRPCNotification(protocol.NotificationRootsListChanged, protocol.NewRootsListChangedNotification()))
+	answerListRoots(newRoots)
+	if got := <-rootsChanged; !reflect.DeepEqual(got, newRoots) {
+		t.Fatalf("changed roots not as expected.\ngot  = %+v\nwant = %+v", got, newRoots)
+	}
+}
+
+func TestServerVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		requested       string
+		expectedVersion string
+	}{
+		{
+			name:            "test_latest_version",
+			requested:       protocol.Version,
+			expectedVersion: protocol.Version,
+		},
+		{
+			name:            "test_older_supported_version",
+			requested:       protocol.Version20241105,
+			expectedVersion: protocol.Version20241105,
+		},
+		{
+			name:            "test_unknown_version",
+			requested:       "1999-01-01",
+			expectedVersion: protocol.Version,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestServer(t)
+			runTestServer(t, server)
+
+			conn.write(protocol.NewJSONRPCRequest("init", protocol.Initialize, protocol.InitializeRequest{ProtocolVersion: tt.requested}))
+
+			var result protocol.InitializeResult
+			if err := pkg.JSONUnmarshal(conn.readResponse().RawResult, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.ProtocolVersion != tt.expectedVersion {
+				t.Fatalf("protocol version not as expected.\ngot  = %s\nwant = %s", result.ProtocolVersion, tt.expectedVersion)
+			}
+
+			var sessionVersion string
+			server.sessionManager.RangeSessions(func(_ string, s *session.State) bool {
+				sessionVersion = s.GetProtocolVersion()
+				return false
+			})
+			if sessionVersion != tt.expectedVersion {
+				t.Fatalf("session protocol version not as expected.\ngot  = %s\nwant = %s", sessionVersion, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	identity := func(s string) string { return s }
+	items := []string{"d", "b", "a", "c", "e"}
+
+	var (
+		got    []string
+		cursor string
+		pages  int
+	)
+	for {
+		page, next, err := paginate(append([]string{}, items...), identity, "test", cursor, 2)
+		if err != nil {
+			t.Fatalf("paginate: %+v", err)
+		}
+		got = append(got, page...)
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+
+		// an item registered before the cursor between two pages must not shift the following pages
+		items = append(items, "0")
+	}
+
+	if expected := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, expected) || pages != 3 {
+		t.Fatalf("paginate not as expected.\ngot  = %v in %d pages\nwant = %v in 3 pages", got, pages, expected)
+	}
+
+	for _, invalid := range []string{"!!!", encodeCursor("other", "a")} {
+		if _, _, err := paginate(items, identity, "test", invalid, 2); !errors.Is(err, pkg.ErrInvalidParams) {
+			t.Fatalf("paginate with cursor %q: expect invalid params error, got %+v", invalid, err)
+		}
+	}
+}
+
+func TestServerListWithNullParams(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	runTestServer(t, server)
+	testServerInit(t, server, conn.in, conn.out)
+
+	conn.writeRaw([]byte(`{"jsonrpc":"2.0","id":"list","method":"tools/list","params":null}`))
+
+	resp := conn.readResponse()
+	if resp.ID != "list" || resp.Error != nil {
+		t.Fatalf("response not as expected.\ngot  = %+v\nwant tools list", resp)
+	}
+	var result protocol.ListToolsResult
+	if err := pkg.JSONUnmarshal(resp.RawResult, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Tools) != 0 || result.NextCursor != "" {
+		t.Fatalf("result not as expected: %+v", result)
+	}
+}
+
+func TestServerCompleteFallback(t *testing.T) {
+	server, conn := newTestServer(t)
+
+	testResourceTemplate := &protocol.ResourceTemplate{
+		URITemplate:   "file:///{dir}/{path}",
+		Name:          "test",
+		VariableEnums: map[string][]string{"dir": {"docs", "data", "src"}},
+	}
+	if err := server.RegisterResourceTemplate(testResourceTemplate, func(*protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error) {
+		return protocol.NewReadResourceResult(nil), nil
+	}); err != nil {
+		t.Fatalf("RegisterResourceTemplate: %+v", err)
+	}
+
+	runTestServer(t, server)
+	testServerInit(t, server, conn.in, conn.out)
+
+	// the variable without a registered completion handler is completed from its enum
+	conn.write(protocol.NewJSONRPCRequest("complete", protocol.CompletionComplete,
+		protocol.NewCompleteRequest("dir", "d", protocol.NewResourceReference(testResourceTemplate.URITemplate))))
+	resp := conn.readResponse()
+	if resp.Error != nil {
+		t.Fatalf("response error: %+v", resp.Error)
+	}
+	var result protocol.CompleteResult
+	if err := pkg.JSONUnmarshal(resp.RawResult, &result); err != nil {
+		t.Fatal(err)
+	}
+	if expected := protocol.NewCompleteResult([]string{"docs", "data"}, false, 2); !reflect.DeepEqual(&result, expected) {
+		t.Fatalf("result not as expected.\ngot  = %+v\nwant = %+v", result, expected)
+	}
+
+	for _, params := range []string{`null`, `{"argument":{"name":"dir","value":"d"}}`} {
+		conn.writeRaw([]byte(`{"jsonrpc":"2.0","id":"invalid","method":"completion/complete","params":` + params + `}`))
+		if resp := conn.readResponse(); resp.Error == nil || resp.Error.Code != protocol.InvalidParams {
+			t.Fatalf("response to params %s not as expected.\ngot  = %+v\nwant invalid params error", params, resp)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
@@ -14,6 +15,9 @@ type Manager struct {
 
 	detection   func(ctx context.Context, sessionID string) error
 	maxIdleTime time.Duration
+
+	closeHandlersMu sync.RWMutex
+	closeHandlers   []func(sessionID string)
 }
 
 func NewManager(detection func(ctx context.Context, sessionID string) error) *Manager {
@@ -27,6 +31,15 @@ func (m *Manager) SetMaxIdleTime(d time.Duration) {
 	m.maxIdleTime = d
 }
 
+// AddSessionCloseHandler registers handler to be called with the id of each session closed,
+// whether by the transport, the heartbeat or the shutdown
+func (m *Manager) AddSessionCloseHandler(handler func(sessionID string)) {
+	m.closeHandlersMu.Lock()
+	defer m.closeHandlersMu.Unlock()
+
+	m.closeHandlers = append(m.closeHandlers, handler)
+}
+
 func (m *Manager) CreateSession(sessionID string) {
 	state := NewState()
 	m.sessions.Store(sessionID, state)
@@ -75,6 +88,7 @@ func (m *Manager) CloseSession(sessionID string) {
 		return
 	}
 	state.Close()
+	m.notifyClosed(sessionID)
 }
 
 func (m *Manager) CloseAllSessions() {
@@ -85,10 +99,20 @@ func (m *Manager) CloseAllSessions() {
 			return true
 		}
 		state.Close()
+		m.notifyClosed(sessionID)
 		return true
 	})
 }
 
+func (m *Manager) notifyClosed(sessionID string) {
+	m.closeHandlersMu.RLock()
+	defer m.closeHandlersMu.RUnlock()
+
+	for _, handler := range m.closeHandlers {
+		handler(sessionID)
+	}
+}
+
 func (m *Manager) StartHeartbeatAndCleanInvalidSessions() {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
@@ -23,13 +23,25 @@ type State struct {
 
 	reqID2respChan cmap.ConcurrentMap[string, chan *protocol.JSONRPCResponse]
 
-	// cache client initialize request info
-	clientInfo         *protocol.Implementation
-	clientCapabilities *protocol.ClientCapabilities
+	// cancel functions of the requests from the client that are still being handled
+	reqID2cancel cmap.ConcurrentMap[string, *context.CancelFunc]
+
+	// cache client initialize request info, read by every request concurrently with the initialize request
+	clientInfo         atomic.Value
+	clientCapabilities atomic.Value
 
 	// subscribed resources
 	subscribedResources cmap.ConcurrentMap[string, struct{}]
 
+	// minimum level of log messages the client wants to receive, empty means no filtering
+	loggingLevel atomic.Value
+
+	// protocol version negotiated in the initialize request
+	protocolVersion atomic.Value
+
+	// roots of the client, cached after the first roots/list and refreshed on notifications/roots/list_changed
+	roots atomic.Value
+
 	receivedInitRequest *pkg.AtomicBool
 	ready               *pkg.AtomicBool
 	closed              *pkg.AtomicBool
@@ -40,6 +52,7 @@ func NewState() *State {
 		lastActiveAt:        time.Now(),
 		sendChan:            make(chan []byte, 64),
 		reqID2respChan:      cmap.New[chan *protocol.JSONRPCResponse](),
+		reqID2cancel:        cmap.New[*context.CancelFunc](),
 		subscribedResources: cmap.New[struct{}](),
 		receivedInitRequest: pkg.NewAtomicBool(),
 		ready:               pkg.NewAtomicBool(),
@@ -48,8 +61,18 @@ func NewState() *State {
 }
 
 func (s *State) SetClientInfo(ClientInfo *protocol.Implementation, ClientCapabilities *protocol.ClientCapabilities) {
-	s.clientInfo = ClientInfo
-	s.clientCapabilities = ClientCapabilities
+	s.clientInfo.Store(ClientInfo)
+	s.clientCapabilities.Store(ClientCapabilities)
+}
+
+func (s *State) GetClientInfo() *protocol.Implementation {
+	clientInfo, _ := s.clientInfo.Load().(*protocol.Implementation)
+	return clientInfo
+}
+
+func (s *State) GetClientCapabilities() *protocol.ClientCapabilities {
+	clientCapabilities, _ := s.clientCapabilities.Load().(*protocol.ClientCapabilities)
+	return clientCapabilities
 }
 
 func (s *State) SetReceivedInitRequest() {
@@ -76,16 +99,52 @@ func (s *State) GetReqID2respChan() cmap.ConcurrentMap[string, chan *protocol.JS
 	return s.reqID2respChan
 }
 
+func (s *State) GetReqID2cancel() cmap.ConcurrentMap[string, *context.CancelFunc] {
+	return s.reqID2cancel
+}
+
 func (s *State) GetSubscribedResources() cmap.ConcurrentMap[string, struct{}] {
 	return s.subscribedResources
 }
 
+func (s *State) SetLoggingLevel(level protocol.LoggingLevel) {
+	s.loggingLevel.Store(level)
+}
+
+func (s *State) GetLoggingLevel() protocol.LoggingLevel {
+	level, _ := s.loggingLevel.Load().(protocol.LoggingLevel)
+	return level
+}
+
+func (s *State) SetProtocolVersion(version string) {
+	s.protocolVersion.Store(version)
+}
+
+func (s *State) GetProtocolVersion() string {
+	version, _ := s.protocolVersion.Load().(string)
+	return version
+}
+
+func (s *State) SetRoots(roots []protocol.Root) {
+	s.roots.Store(roots)
+}
+
+// GetRoots returns the cached roots of the client, ok is false when they have not been listed yet
+func (s *State) GetRoots() (roots []protocol.Root, ok bool) {
+	roots, ok = s.roots.Load().([]protocol.Root)
+	return roots, ok
+}
+
 func (s *State) Close() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.closed.Store(true)
 	close(s.sendChan)
+
+	for _, cancel := range s.reqID2cancel.Items() {
+		(*cancel)()
+	}
 }
 
 func (s *State) updateLastActiveAt() {
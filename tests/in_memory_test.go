@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/client"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+)
+
+type echoReq struct {
+	Text string `json:"text" description:"text to echo" required:"true"`
+}
+
+func TestInMemory(t *testing.T) {
+	transportClient, transportServer := transport.NewInMemoryPair()
+
+	srv, err := server.NewServer(transportServer)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	tool, err := protocol.NewTool("echo", "Echo the text", echoReq{})
+	if err != nil {
+		t.Fatalf("Failed to create tool: %v", err)
+	}
+	srv.RegisterTool(tool, func(request *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		req := new(echoReq)
+		if err := protocol.VerifyAndUnmarshal(request.RawArguments, &req); err != nil {
+			return nil, err
+		}
+		return &protocol.CallToolResult{Content: []protocol.Content{protocol.TextContent{Type: "text", Text: req.Text}}}, nil
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Run()
+	}()
+
+	// concurrent clients, each with its own session
+	transportClients := []transport.ClientTransport{transportClient}
+	for i := 0; i < 2; i++ {
+		c, err := transport.NewInMemoryClientTransport(transportServer)
+		if err != nil {
+			t.Fatalf("Failed to create transport client: %v", err)
+		}
+		transportClients = append(transportClients, c)
+	}
+
+	var wg sync.WaitGroup
+	mcpClients := make([]*client.Client, len(transportClients))
+	for i, c := range transportClients {
+		wg.Add(1)
+		go func(i int, c transport.ClientTransport) {
+			defer wg.Done()
+
+			mcpClient, err := client.NewClient(c)
+			if err != nil {
+				t.Errorf("Failed to create MCP client: %v", err)
+				return
+			}
+			mcpClients[i] = mcpClient
+
+			text := fmt.Sprintf("client %d", i)
+			result, err := mcpClient.CallTool(context.Background(),
+				protocol.NewCallToolRequest("echo", map[string]interface{}{"text": text}))
+			if err != nil {
+				t.Errorf("Failed to call tool: %v", err)
+				return
+			}
+			if got, ok := result.Content[0].(protocol.TextContent); !ok || got.Text != text {
+				t.Errorf("tool result got %+v, want %s", result.Content[0], text)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shutdown server: %v", err)
+	}
+	if err = <-errCh; err != nil {
+		t.Fatalf("server.Run() failed: %v", err)
+	}
+
+	for _, mcpClient := range mcpClients {
+		if mcpClient == nil {
+			continue
+		}
+		if err = mcpClient.Close(); err != nil {
+			t.Fatalf("Failed to close MCP client: %v", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/client"
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+)
+
+func TestPagination(t *testing.T) {
+	transportServer := transport.NewInMemoryServerTransport()
+
+	// 5 tools listed 2 by 2
+	srv, err := server.NewServer(transportServer, server.WithPageSize(2))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	var allTools []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("tool%d", i)
+		tool, err := protocol.NewTool(name, "Echo the text", echoReq{})
+		if err != nil {
+			t.Fatalf("Failed to create tool: %v", err)
+		}
+		srv.RegisterTool(tool, func(*protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			return &protocol.CallToolResult{}, nil
+		})
+		allTools = append(allTools, name)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Run()
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			t.Fatalf("Failed to shutdown server: %v", err)
+		}
+		if err := <-errCh; err != nil {
+			t.Fatalf("server.Run() failed: %v", err)
+		}
+	}()
+
+	newClient := func(t *testing.T, opts ...client.Option) *client.Client {
+		transportClient, err := transport.NewInMemoryClientTransport(transportServer)
+		if err != nil {
+			t.Fatalf("Failed to create transport client: %v", err)
+		}
+		mcpClient, err := client.NewClient(transportClient, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create MCP client: %v", err)
+		}
+		t.Cleanup(func() { _ = mcpClient.Close() })
+		return mcpClient
+	}
+
+	t.Run("test_list_pages", func(t *testing.T) {
+		mcpClient := newClient(t)
+
+		var (
+			tools  []string
+			cursor string
+		)
+		for pages := 1; ; pages++ {
+			result, err := mcpClient.ListToolsPage(context.Background(), cursor)
+			if err != nil {
+				t.Fatalf("Failed to list tools: %v", err)
+			}
+			if len(result.Tools) > 2 {
+				t.Fatalf("page %d has %d tools, want at most 2", pages, len(result.Tools))
+			}
+			for _, tool := range result.Tools {
+				tools = append(tools, tool.Name)
+				// the cursor is opaque, it does not give away the name of the last tool of the page
+				if result.NextCursor != "" && strings.Contains(result.NextCursor, tool.Name) {
+					t.Fatalf("cursor %q shows the tool name %s", result.NextCursor, tool.Name)
+				}
+			}
+			// the end of the list has no next cursor
+			if result.NextCursor == "" {
+				if pages != 3 {
+					t.Fatalf("pages got %d, want 3", pages)
+				}
+				break
+			}
+			cursor = result.NextCursor
+		}
+		if strings.Join(tools, ",") != strings.Join(allTools, ",") {
+			t.Fatalf("tools got %v, want %v", tools, allTools)
+		}
+	})
+
+	t.Run("test_invalid_cursor", func(t *testing.T) {
+		mcpClient := newClient(t)
+
+		_, err := mcpClient.ListToolsPage(context.Background(), "not-a-cursor")
+		var respErr *pkg.ResponseError
+		if !errors.As(err, &respErr) || respErr.Code != protocol.InvalidParams {
+			t.Fatalf("ListToolsPage error got %v, want code %d", err, protocol.InvalidParams)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		opts          []client.Option
+		stopAfter     int
+		expectedTools []string
+		expectedErr   bool
+	}{
+		{
+			name:          "test_range_all_pages",
+			expectedTools: allTools,
+		},
+		{
+			name:          "test_range_early_stop",
+			stopAfter:     3,
+			expectedTools: allTools[:3],
+		},
+		{
+			name:          "test_range_max_pages_exceeded",
+			opts:          []client.Option{client.WithMaxListPages(2)},
+			expectedTools: allTools[:4],
+			expectedErr:   true,
+		},
+		{
+			name:          "test_range_max_pages_reached",
+			opts:          []client.Option{client.WithMaxListPages(3)},
+			expectedTools: allTools,
+		},
+		{
+			name:          "test_range_max_pages_disabled",
+			opts:          []client.Option{client.WithMaxListPages(0)},
+			expectedTools: allTools,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mcpClient := newClient(t, tt.opts...)
+
+			var tools []string
+			err := mcpClient.RangeTools(context.Background(), func(tool *protocol.Tool) bool {
+				tools = append(tools, tool.Name)
+				return tt.stopAfter == 0 || len(tools) < tt.stopAfter
+			})
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("RangeTools error got %v, want error %v", err, tt.expectedErr)
+			}
+			if strings.Join(tools, ",") != strings.Join(tt.expectedTools, ",") {
+				t.Fatalf("tools got %v, want %v", tools, tt.expectedTools)
+			}
+		})
+	}
+}
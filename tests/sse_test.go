@@ -3,9 +3,6 @@ package tests
 import (
 	"fmt"
 	"net"
-	"os"
-	"os/exec"
-	"strconv"
 	"testing"
 
 	"github.com/ThinkInAIXYZ/go-mcp/transport"
@@ -22,7 +19,7 @@ func TestSSE(t *testing.T) {
 		t.Fatalf("Failed to create transport client: %v", err)
 	}
 
-	test(t, func() error { return runSSEServer(port) }, transportClient)
+	test(t, func() error { return runMockServer("sse", port) }, transportClient)
 }
 
 // getAvailablePort returns a port that is available for use
@@ -40,19 +37,3 @@ func getAvailablePort() (int, error) {
 	port := addr.Addr().(*net.TCPAddr).Port
 	return port, nil
 }
-
-func runSSEServer(port int) error {
-	mockServerTrPath, err := compileMockStdioServerTr()
-	if err != nil {
-		return err
-	}
-	fmt.Println(mockServerTrPath)
-
-	defer func(name string) {
-		if err := os.Remove(name); err != nil {
-			fmt.Printf("failed to remove mock server: %v\n", err)
-		}
-	}(mockServerTrPath)
-
-	return exec.Command(mockServerTrPath, "-transport", "sse", "-port", strconv.Itoa(port)).Run()
-}
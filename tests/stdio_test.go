@@ -1,10 +1,14 @@
 package tests
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/ThinkInAIXYZ/go-mcp/client"
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
@@ -30,3 +34,25 @@ func TestStdio(t *testing.T) {
 		return nil
 	}, transportClient)
 }
+
+func TestStdioServerExit(t *testing.T) {
+	transportClient, err := transport.NewStdioClientTransport("sh", []string{"-c", "read line; echo fatal error >&2; exit 2"})
+	if err != nil {
+		t.Fatalf("Failed to create transport client: %v", err)
+	}
+
+	// the initialization fails once the server exits, instead of waiting for its timeout
+	start := time.Now()
+	_, err = client.NewClient(transportClient, client.WithInitTimeout(time.Minute))
+
+	var exitErr *pkg.ProcessExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("NewClient error got %v, want *pkg.ProcessExitError", err)
+	}
+	if exitErr.ExitCode != 2 || exitErr.Stderr != "fatal error" {
+		t.Fatalf("exit error got code %d stderr %q", exitErr.ExitCode, exitErr.Stderr)
+	}
+	if time.Since(start) > 30*time.Second {
+		t.Fatalf("process exit detected after %s", time.Since(start))
+	}
+}
@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+)
+
+func TestStreamableHTTP(t *testing.T) {
+	port, err := getAvailablePort()
+	if err != nil {
+		t.Fatalf("Failed to get available port: %v", err)
+	}
+
+	transportClient, err := transport.NewStreamableHTTPClientTransport(fmt.Sprintf("http://127.0.0.1:%d/mcp", port))
+	if err != nil {
+		t.Fatalf("Failed to create transport client: %v", err)
+	}
+
+	test(t, func() error { return runMockServer("streamable_http", port) }, transportClient)
+}
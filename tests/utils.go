@@ -78,3 +78,19 @@ func compileMockStdioServerTr() (string, error) {
 
 	return mockServerTrPath, nil
 }
+
+// runMockServer runs the mock server listening on port with the network transport named transportName
+func runMockServer(transportName string, port int) error {
+	mockServerTrPath, err := compileMockStdioServerTr()
+	if err != nil {
+		return err
+	}
+
+	defer func(name string) {
+		if err := os.Remove(name); err != nil {
+			fmt.Printf("failed to remove mock server: %v\n", err)
+		}
+	}(mockServerTrPath)
+
+	return exec.Command(mockServerTrPath, "-transport", transportName, "-port", strconv.Itoa(port)).Run()
+}
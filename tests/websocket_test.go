@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+)
+
+func TestWebSocket(t *testing.T) {
+	port, err := getAvailablePort()
+	if err != nil {
+		t.Fatalf("Failed to get available port: %v", err)
+	}
+
+	transportClient, err := transport.NewWebSocketClientTransport(fmt.Sprintf("ws://127.0.0.1:%d/ws", port))
+	if err != nil {
+		t.Fatalf("Failed to create transport client: %v", err)
+	}
+
+	test(t, func() error { return runMockServer("websocket", port) }, transportClient)
+}
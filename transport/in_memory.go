@@ -0,0 +1,283 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+var errInMemorySessionClosed = errors.New("in-memory session closed")
+
+type InMemoryServerTransportOption func(*inMemoryServerTransport)
+
+func WithInMemoryServerTransportOptionLogger(logger pkg.Logger) InMemoryServerTransportOption {
+	return func(t *inMemoryServerTransport) {
+		t.logger = logger
+	}
+}
+
+type InMemoryClientTransportOption func(*inMemoryClientTransport)
+
+func WithInMemoryClientTransportOptionReceiveTimeout(timeout time.Duration) InMemoryClientTransportOption {
+	return func(t *inMemoryClientTransport) {
+		t.receiveTimeout = timeout
+	}
+}
+
+func WithInMemoryClientTransportOptionLogger(logger pkg.Logger) InMemoryClientTransportOption {
+	return func(t *inMemoryClientTransport) {
+		t.logger = logger
+	}
+}
+
+// NewInMemoryPair returns a client transport connected to a new server transport in the same process,
+// more clients can be connected to the server with NewInMemoryClientTransport.
+func NewInMemoryPair() (ClientTransport, ServerTransport) {
+	server := NewInMemoryServerTransport()
+	client, _ := NewInMemoryClientTransport(server)
+	return client, server
+}
+
+// NewInMemoryServerTransport returns transport serving the clients of NewInMemoryClientTransport, each client being a session
+func NewInMemoryServerTransport(opts ...InMemoryServerTransportOption) ServerTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &inMemoryServerTransport{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: pkg.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// NewInMemoryClientTransport returns transport connected to server when started, server must be an in-memory server transport
+func NewInMemoryClientTransport(server ServerTransport, opts ...InMemoryClientTransportOption) (ClientTransport, error) {
+	svr, ok := server.(*inMemoryServerTransport)
+	if !ok {
+		return nil, fmt.Errorf("server transport %T is not an in-memory transport", server)
+	}
+
+	t := &inMemoryClientTransport{
+		server:          svr,
+		receiveShutDone: make(chan struct{}),
+		logger:          pkg.DefaultLogger,
+		receiveTimeout:  time.Second * 30,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
+}
+
+// inMemorySession carries the messages of a client and its session, in both directions
+type inMemorySession struct {
+	id string
+
+	// ctx is done once the client or the server ends the session
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	toServer chan Message
+	toClient chan Message
+}
+
+type inMemoryServerTransport struct {
+	// ctx is the context that controls the lifecycle of the server.
+	// It is used to coordinate cancellation of all ongoing send operations when the server is shutting down.
+	ctx context.Context
+	// cancel is the function to cancel the ctx when the server needs to shut down.
+	cancel context.CancelFunc
+
+	inFlySend sync.WaitGroup
+
+	receiver serverReceiver
+
+	sessionManager sessionManager
+
+	// options
+	logger pkg.Logger
+}
+
+func (t *inMemoryServerTransport) Run() error {
+	<-t.ctx.Done()
+	return nil
+}
+
+func (t *inMemoryServerTransport) Send(ctx context.Context, sessionID string, msg Message) error {
+	t.inFlySend.Add(1)
+	defer t.inFlySend.Done()
+
+	select {
+	case <-t.ctx.Done():
+		return errors.New("in-memory server transport already shutdown")
+	default:
+	}
+
+	return t.sessionManager.SendMessage(ctx, sessionID, msg)
+}
+
+func (t *inMemoryServerTransport) SetReceiver(receiver serverReceiver) {
+	t.receiver = receiver
+}
+
+func (t *inMemoryServerTransport) SetSessionManager(manager sessionManager) {
+	t.sessionManager = manager
+}
+
+// connect creates the session of a client, whose messages are received until the session ends
+func (t *inMemoryServerTransport) connect() (*inMemorySession, error) {
+	if t.ctx.Err() != nil {
+		return nil, errors.New("in-memory server transport already shutdown")
+	}
+	if t.sessionManager == nil || t.receiver == nil {
+		return nil, errors.New("in-memory server transport not attached to a server")
+	}
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	s := &inMemorySession{
+		id:       uuid.New().String(),
+		ctx:      ctx,
+		cancel:   cancel,
+		toServer: make(chan Message),
+		toClient: make(chan Message),
+	}
+	t.sessionManager.CreateSession(s.id)
+
+	go func() {
+		defer pkg.Recover()
+		defer t.sessionManager.CloseSession(s.id)
+
+		t.receive(s)
+	}()
+
+	go func() {
+		defer pkg.Recover()
+		// the session is over for the client once the server closes it
+		defer s.cancel()
+
+		for {
+			msg, err := t.sessionManager.GetMessageForSend(s.ctx, s.id)
+			if err != nil {
+				return
+			}
+
+			select {
+			case s.toClient <- msg:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+func (t *inMemoryServerTransport) receive(s *inMemorySession) {
+	for {
+		select {
+		case msg := <-s.toServer:
+			if err := t.receiver.Receive(s.ctx, s.id, msg); err != nil {
+				t.logger.Errorf("receiver failed: %v, sessionID=%s", err, s.id)
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *inMemoryServerTransport) Shutdown(userCtx context.Context, serverCtx context.Context) error {
+	select {
+	case <-serverCtx.Done():
+	case <-userCtx.Done():
+		return userCtx.Err()
+	}
+
+	t.cancel()
+
+	t.inFlySend.Wait()
+
+	t.sessionManager.CloseAllSessions()
+
+	return nil
+}
+
+type inMemoryClientTransport struct {
+	server  *inMemoryServerTransport
+	session *inMemorySession
+
+	receiver clientReceiver
+
+	receiveShutDone chan struct{}
+
+	// options
+	logger         pkg.Logger
+	receiveTimeout time.Duration
+}
+
+func (t *inMemoryClientTransport) Start() error {
+	s, err := t.server.connect()
+	if err != nil {
+		return err
+	}
+	t.session = s
+
+	go func() {
+		defer pkg.Recover()
+		defer close(t.receiveShutDone)
+
+		t.receive()
+	}()
+	return nil
+}
+
+func (t *inMemoryClientTransport) receive() {
+	for {
+		select {
+		case msg := <-t.session.toClient:
+			ctx, cancel := context.WithTimeout(t.session.ctx, t.receiveTimeout)
+			if err := t.receiver.Receive(ctx, msg); err != nil {
+				t.logger.Errorf("Error receive message: %v", err)
+			}
+			cancel()
+		case <-t.session.ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *inMemoryClientTransport) Send(ctx context.Context, msg Message) error {
+	select {
+	case t.session.toServer <- msg:
+		return nil
+	case <-t.session.ctx.Done():
+		return errInMemorySessionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *inMemoryClientTransport) SetReceiver(receiver clientReceiver) {
+	t.receiver = receiver
+}
+
+// Close ends the session, the server closing it on its side
+func (t *inMemoryClientTransport) Close() error {
+	if t.session == nil {
+		return nil
+	}
+
+	t.session.cancel()
+
+	<-t.receiveShutDone
+
+	return nil
+}
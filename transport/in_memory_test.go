@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInMemoryTransport(t *testing.T) {
+	client, svr := NewInMemoryPair()
+
+	testTransport(t, client, newTestSessionServerTransport(svr))
+}
+
+func TestInMemoryClientTransport(t *testing.T) {
+	if _, err := NewInMemoryClientTransport(NewStdioServerTransport()); err == nil {
+		t.Fatalf("NewInMemoryClientTransport with a stdio server transport succeeded")
+	}
+}
+
+func TestInMemorySendAfterShutdown(t *testing.T) {
+	svr := NewInMemoryServerTransport()
+	svr.SetSessionManager(newMockSessionManager())
+
+	serverCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := svr.Shutdown(context.Background(), serverCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if err := svr.Send(context.Background(), "session", Message(`{}`)); err == nil {
+		t.Fatalf("send after shutdown: got nil error")
+	}
+}
@@ -0,0 +1,21 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+)
+
+// isOriginAllowed reports whether the Origin of r is one of allowedOrigins, guarding browsers against DNS rebinding.
+// Every origin is allowed when allowedOrigins is empty, and so are the requests without Origin, which do not come from a browser.
+func isOriginAllowed(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if len(allowedOrigins) == 0 || origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+type SocketClientTransportOption func(*socketClientTransport)
+
+func WithSocketClientOptionLogger(log pkg.Logger) SocketClientTransportOption {
+	return func(t *socketClientTransport) {
+		t.logger = log
+	}
+}
+
+func WithSocketClientOptionReceiveTimeout(timeout time.Duration) SocketClientTransportOption {
+	return func(t *socketClientTransport) {
+		t.receiveTimeout = timeout
+	}
+}
+
+// WithSocketClientOptionDialTimeout sets the timeout of the dial of a transport created by NewSocketClientTransportWithDial
+func WithSocketClientOptionDialTimeout(timeout time.Duration) SocketClientTransportOption {
+	return func(t *socketClientTransport) {
+		t.dialTimeout = timeout
+	}
+}
+
+type socketClientTransport struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// dial opens conn on Start, it is nil when conn is given
+	dial func(ctx context.Context) (io.ReadWriteCloser, error)
+
+	conn     io.ReadWriteCloser
+	writeMu  sync.Mutex
+	receiver clientReceiver
+
+	started         bool
+	receiveShutDone chan struct{}
+
+	stateMu       sync.Mutex
+	stateHandlers []func(state ConnectionState)
+	// connErr is the cause of the loss of the connection, set before ConnectionStateDisconnected is reported
+	connErr error
+
+	// options
+	logger         pkg.Logger
+	receiveTimeout time.Duration
+	dialTimeout    time.Duration
+}
+
+// NewSocketClientTransport returns transport exchanging newline-delimited messages over conn,
+// which is closed with the transport.
+func NewSocketClientTransport(conn io.ReadWriteCloser, opts ...SocketClientTransportOption) ClientTransport {
+	t := newSocketClientTransport(opts...)
+	t.conn = conn
+	return t
+}
+
+// NewSocketClientTransportWithDial returns transport connecting to address on the named network when started,
+// such as "tcp" or "unix", see net.Dial.
+func NewSocketClientTransportWithDial(network, address string, opts ...SocketClientTransportOption) ClientTransport {
+	t := newSocketClientTransport(opts...)
+	t.dial = func(ctx context.Context) (io.ReadWriteCloser, error) {
+		return (&net.Dialer{Timeout: t.dialTimeout}).DialContext(ctx, network, address)
+	}
+	return t
+}
+
+func newSocketClientTransport(opts ...SocketClientTransportOption) *socketClientTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &socketClientTransport{
+		ctx:             ctx,
+		cancel:          cancel,
+		receiveShutDone: make(chan struct{}),
+		logger:          pkg.DefaultLogger,
+		receiveTimeout:  time.Second * 30,
+		dialTimeout:     time.Second * 10,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *socketClientTransport) Start() error {
+	if t.dial != nil {
+		conn, err := t.dial(t.ctx)
+		if err != nil {
+			return fmt.Errorf("failed to dial: %w", err)
+		}
+		t.conn = conn
+	}
+	t.started = true
+
+	go func() {
+		defer pkg.Recover()
+		defer close(t.receiveShutDone)
+
+		t.receive()
+	}()
+
+	t.notifyState(ConnectionStateConnected)
+	return nil
+}
+
+func (t *socketClientTransport) receive() {
+	s := bufio.NewScanner(t.conn)
+	s.Buffer(nil, socketMaxMessageSize)
+
+	for s.Scan() {
+		// filter empty messages
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(t.ctx, t.receiveTimeout)
+		if err := t.receiver.Receive(ctx, s.Bytes()); err != nil {
+			t.logger.Errorf("receiver failed: %v", err)
+		}
+		cancel()
+	}
+
+	if t.ctx.Err() != nil {
+		return
+	}
+
+	err := s.Err()
+	if err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.ErrClosedPipe) {
+		t.logger.Errorf("client receive unexpected error reading input: %v", err)
+	}
+	if err == nil {
+		err = io.EOF
+	}
+
+	// the connection was dropped by the server
+	t.stateMu.Lock()
+	t.connErr = fmt.Errorf("%w: %v", pkg.ErrConnectionLost, err)
+	t.stateMu.Unlock()
+	t.notifyState(ConnectionStateDisconnected)
+}
+
+// Send writes msg until ctx is done, which only interrupts the write when conn supports write deadlines, as net.Conn does
+func (t *socketClientTransport) Send(ctx context.Context, msg Message) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if conn, ok := t.conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		deadline, _ := ctx.Deadline()
+		if err := conn.SetWriteDeadline(deadline); err != nil {
+			return fmt.Errorf("failed to set write deadline: %w", err)
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				// a deadline in the past interrupts the pending write
+				_ = conn.SetWriteDeadline(time.Unix(1, 0))
+			case <-done:
+			}
+		}()
+	}
+
+	if _, err := t.conn.Write(append(msg, mcpMessageDelimiter)); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to write: %w", err)
+	}
+	return nil
+}
+
+func (t *socketClientTransport) SetReceiver(receiver clientReceiver) {
+	t.receiver = receiver
+}
+
+func (t *socketClientTransport) AddConnectionStateHandler(handler func(state ConnectionState)) {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+
+	t.stateHandlers = append(t.stateHandlers, handler)
+}
+
+// ConnectionError returns the cause of the loss of the connection, matching pkg.ErrConnectionLost with errors.Is
+func (t *socketClientTransport) ConnectionError() error {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+
+	return t.connErr
+}
+
+func (t *socketClientTransport) notifyState(state ConnectionState) {
+	t.logger.Debugf("socket connection state: %s", state)
+
+	t.stateMu.Lock()
+	handlers := append([]func(ConnectionState){}, t.stateHandlers...)
+	t.stateMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(state)
+	}
+}
+
+func (t *socketClientTransport) Close() error {
+	t.cancel()
+
+	if t.conn == nil {
+		return nil
+	}
+
+	if err := t.conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("failed to close connection: %w", err)
+	}
+
+	if t.started {
+		<-t.receiveShutDone
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// socketMaxMessageSize bounds a newline-delimited message read from a socket
+const socketMaxMessageSize = 32 << 20
+
+type SocketServerTransportOption func(*socketServerTransport)
+
+func WithSocketServerOptionLogger(log pkg.Logger) SocketServerTransportOption {
+	return func(t *socketServerTransport) {
+		t.logger = log
+	}
+}
+
+type socketServerTransport struct {
+	// ctx is the context that controls the lifecycle of the server.
+	// It is used to coordinate cancellation of all ongoing send operations when the server is shutting down.
+	ctx context.Context
+	// cancel is the function to cancel the ctx when the server needs to shut down.
+	cancel context.CancelFunc
+
+	listener net.Listener
+
+	inFlySend sync.WaitGroup
+	// connections being served, waited for on Shutdown
+	inFlyConn sync.WaitGroup
+
+	receiver serverReceiver
+
+	sessionManager sessionManager
+
+	logger pkg.Logger
+}
+
+// NewSocketServerTransport returns transport accepting connections from listener, such as a TCP or Unix domain socket,
+// each connection being a session exchanging newline-delimited messages.
+func NewSocketServerTransport(listener net.Listener, opts ...SocketServerTransportOption) ServerTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &socketServerTransport{
+		ctx:      ctx,
+		cancel:   cancel,
+		listener: listener,
+		logger:   pkg.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *socketServerTransport) Run() error {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if t.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.logger.Warnf("socket accept failed: %v", err)
+				continue
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		t.inFlyConn.Add(1)
+		go func() {
+			defer pkg.Recover()
+			defer t.inFlyConn.Done()
+
+			t.serve(conn)
+		}()
+	}
+}
+
+func (t *socketServerTransport) Send(ctx context.Context, sessionID string, msg Message) error {
+	t.inFlySend.Add(1)
+	defer t.inFlySend.Done()
+
+	select {
+	case <-t.ctx.Done():
+		return errors.New("socket server transport already shutdown")
+	default:
+	}
+
+	return t.sessionManager.SendMessage(ctx, sessionID, msg)
+}
+
+func (t *socketServerTransport) SetReceiver(receiver serverReceiver) {
+	t.receiver = receiver
+}
+
+func (t *socketServerTransport) SetSessionManager(manager sessionManager) {
+	t.sessionManager = manager
+}
+
+// serve reads the messages of a connection until it is closed, while the messages sent
+// to its session are written by another goroutine, which closes the connection once the session is closed.
+func (t *socketServerTransport) serve(conn net.Conn) {
+	sessionID := uuid.New().String()
+	t.sessionManager.CreateSession(sessionID)
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	writeDone := make(chan struct{})
+	defer func() {
+		cancel()
+		<-writeDone
+		t.sessionManager.CloseSession(sessionID)
+		_ = conn.Close()
+	}()
+
+	go func() {
+		defer pkg.Recover()
+		defer close(writeDone)
+		// unblocks the reading once the session is closed
+		defer conn.Close()
+
+		for {
+			msg, err := t.sessionManager.GetMessageForSend(ctx, sessionID)
+			if err != nil {
+				return
+			}
+
+			t.logger.Debugf("Sending message: %s", string(msg))
+
+			if _, err = conn.Write(append(msg, mcpMessageDelimiter)); err != nil {
+				t.logger.Errorf("Failed to write message: %v, sessionID=%s", err, sessionID)
+				return
+			}
+		}
+	}()
+
+	s := bufio.NewScanner(conn)
+	s.Buffer(nil, socketMaxMessageSize)
+
+	for s.Scan() {
+		// filter empty messages
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		if err := t.receiver.Receive(ctx, sessionID, s.Bytes()); err != nil {
+			t.logger.Errorf("receiver failed: %v, sessionID=%s", err, sessionID)
+		}
+	}
+
+	if err := s.Err(); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+		t.logger.Errorf("socket read failed: %v, sessionID=%s", err, sessionID)
+	}
+}
+
+func (t *socketServerTransport) Shutdown(userCtx context.Context, serverCtx context.Context) error {
+	// stop accepting connections
+	if err := t.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		t.logger.Warnf("failed to close listener: %v", err)
+	}
+
+	select {
+	case <-serverCtx.Done():
+	case <-userCtx.Done():
+		return userCtx.Err()
+	}
+
+	t.cancel()
+
+	t.inFlySend.Wait()
+
+	t.sessionManager.CloseAllSessions()
+
+	connDone := make(chan struct{})
+	go func() {
+		defer pkg.Recover()
+
+		t.inFlyConn.Wait()
+		close(connDone)
+	}()
+
+	select {
+	case <-connDone:
+		return nil
+	case <-userCtx.Done():
+		return userCtx.Err()
+	}
+}
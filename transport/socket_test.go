@@ -0,0 +1,165 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+func TestSocketTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	svr := NewSocketServerTransport(listener)
+	client := NewSocketClientTransportWithDial("tcp", listener.Addr().String())
+
+	testTransport(t, client, newTestSessionServerTransport(svr))
+}
+
+func TestSocketUnix(t *testing.T) {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "mcp.sock"))
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	svr := NewSocketServerTransport(listener)
+	sessionManager := newMockSessionManager()
+	svr.SetSessionManager(sessionManager)
+
+	sessionIDCh := make(chan string, 2)
+	svr.SetReceiver(ServerReceiverF(func(_ context.Context, sessionID string, _ []byte) error {
+		sessionIDCh <- sessionID
+		return nil
+	}))
+	go func() {
+		if err := svr.Run(); err != nil {
+			t.Errorf("server.Run() failed: %v", err)
+		}
+	}()
+
+	// every connection is a session of its own
+	var clients []*socketClientTransport
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("unix", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		client := NewSocketClientTransport(conn)
+		client.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+		if err = client.Start(); err != nil {
+			t.Fatalf("client.Start() failed: %v", err)
+		}
+		if err = client.Send(context.Background(), Message("{}")); err != nil {
+			t.Fatalf("client.Send() failed: %v", err)
+		}
+		clients = append(clients, client.(*socketClientTransport))
+	}
+	sessionID1, sessionID2 := <-sessionIDCh, <-sessionIDCh
+	if sessionID1 == sessionID2 {
+		t.Fatalf("connections share the session %s", sessionID1)
+	}
+
+	// closing a session closes its connection only
+	sessionManager.CloseSession(sessionID1)
+	closed := 0
+	for _, client := range clients {
+		select {
+		case <-client.receiveShutDone:
+			closed++
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	if closed != 1 {
+		t.Fatalf("closed connections got %d, want 1", closed)
+	}
+
+	userCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	serverCtx, cancel := context.WithCancel(userCtx)
+	cancel()
+	if err = svr.Shutdown(userCtx, serverCtx); err != nil {
+		t.Fatalf("server.Shutdown() failed: %v", err)
+	}
+	for _, client := range clients {
+		if err = client.Close(); err != nil {
+			t.Fatalf("client.Close() failed: %v", err)
+		}
+	}
+}
+
+func TestSocketSendAfterShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	svr := NewSocketServerTransport(listener)
+	svr.SetSessionManager(newMockSessionManager())
+
+	serverCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = svr.Shutdown(context.Background(), serverCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if err = svr.Send(context.Background(), "session", Message(`{}`)); err == nil {
+		t.Fatalf("send after shutdown: got nil error")
+	}
+}
+
+func TestSocketClientSendTimeout(t *testing.T) {
+	// nothing reads the other end of the pipe, so writes block
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+
+	client := NewSocketClientTransport(clientConn)
+	client.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+	if err := client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.Send(ctx, Message(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("client.Send() error got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSocketClientConnectionLost(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+
+	client := NewSocketClientTransport(clientConn)
+	client.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+	states := make(chan ConnectionState, 2)
+	client.(ConnectionStateNotifier).AddConnectionStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+	if err := client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer client.Close()
+
+	_ = serverConn.Close()
+
+	for _, want := range []ConnectionState{ConnectionStateConnected, ConnectionStateDisconnected} {
+		select {
+		case got := <-states:
+			if got != want {
+				t.Fatalf("connection state got %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("connection state %s not reported", want)
+		}
+	}
+	if err := client.(ConnectionErrorReporter).ConnectionError(); !errors.Is(err, pkg.ErrConnectionLost) {
+		t.Fatalf("connection error got %v, want %v", err, pkg.ErrConnectionLost)
+	}
+}
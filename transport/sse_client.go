@@ -6,9 +6,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
@@ -34,20 +36,56 @@ func WithSSEClientOptionLogger(log pkg.Logger) SSEClientTransportOption {
 	}
 }
 
+// WithSSEClientOptionMaxReconnectAttempts sets the number of attempts to reconnect a dropped stream
+// before giving up, 0 disables reconnection.
+func WithSSEClientOptionMaxReconnectAttempts(attempts int) SSEClientTransportOption {
+	return func(t *sseClientTransport) {
+		t.maxReconnectAttempts = attempts
+	}
+}
+
+// WithSSEClientOptionReconnectBackoff sets the delay before the first reconnection attempt,
+// doubled on each failed attempt up to maxDelay. initialDelay must be positive and maxDelay not below it.
+func WithSSEClientOptionReconnectBackoff(initialDelay, maxDelay time.Duration) SSEClientTransportOption {
+	return func(t *sseClientTransport) {
+		t.reconnectInitialDelay = initialDelay
+		t.reconnectMaxDelay = maxDelay
+	}
+}
+
+// WithSSEClientOptionConnectionStateHandler sets handler to be called on each connection state change
+func WithSSEClientOptionConnectionStateHandler(handler func(state ConnectionState)) SSEClientTransportOption {
+	return func(t *sseClientTransport) {
+		t.stateHandlers = append(t.stateHandlers, handler)
+	}
+}
+
 type sseClientTransport struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	serverURL *url.URL
 
-	endpointChan    chan struct{}
+	endpointChan chan struct{}
+	endpointOnce sync.Once
+	receiver     clientReceiver
+
+	// messageEndpoint changes when a reconnection gets a new session,
+	// lastEventID is sent when reconnecting so that the server resumes the session.
+	mu              sync.RWMutex
 	messageEndpoint *url.URL
-	receiver        clientReceiver
+	lastEventID     string
+
+	stateMu       sync.Mutex
+	stateHandlers []func(state ConnectionState)
 
 	// options
-	logger         pkg.Logger
-	receiveTimeout time.Duration
-	client         *http.Client
+	logger                pkg.Logger
+	receiveTimeout        time.Duration
+	client                *http.Client
+	maxReconnectAttempts  int
+	reconnectInitialDelay time.Duration
+	reconnectMaxDelay     time.Duration
 
 	sseConnectClose chan struct{}
 }
@@ -71,12 +109,21 @@ func NewSSEClientTransport(serverURL string, opts ...SSEClientTransportOption) (
 		receiveTimeout:  time.Second * 30,
 		client:          http.DefaultClient,
 		sseConnectClose: make(chan struct{}),
+
+		maxReconnectAttempts:  5,
+		reconnectInitialDelay: 500 * time.Millisecond,
+		reconnectMaxDelay:     30 * time.Second,
 	}
 
 	for _, opt := range opts {
 		opt(x)
 	}
 
+	if x.reconnectInitialDelay <= 0 || x.reconnectMaxDelay < x.reconnectInitialDelay {
+		cancel()
+		return nil, fmt.Errorf("invalid reconnect backoff: initialDelay=%s, maxDelay=%s", x.reconnectInitialDelay, x.reconnectMaxDelay)
+	}
+
 	return x, nil
 }
 
@@ -84,43 +131,22 @@ func (t *sseClientTransport) Start() error {
 	errChan := make(chan error, 1)
 	go func() {
 		defer pkg.Recover()
+		defer close(t.sseConnectClose)
 
-		req, err := http.NewRequest(http.MethodGet, t.serverURL.String(), nil)
+		resp, err := t.connect("")
 		if err != nil {
-			errChan <- fmt.Errorf("failed to create request: %w", err)
+			errChan <- err
 			return
 		}
 
-		req.Header.Set("Accept", "text/event-stream")
-		req.Header.Set("Cache-Control", "no-cache")
-		req.Header.Set("Connection", "keep-alive")
+		for resp != nil {
+			t.readSSE(resp.Body)
 
-		resp, err := t.client.Do(req) //nolint:bodyclose
-		if err != nil {
-			errChan <- fmt.Errorf("failed to connect to SSE stream: %w", err)
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			errChan <- fmt.Errorf("unexpected status code: %d, status: %s", resp.StatusCode, resp.Status)
-			return
-		}
-
-		go func() {
-			defer pkg.Recover()
-
-			<-t.ctx.Done()
-
-			if err := resp.Body.Close(); err != nil {
-				t.logger.Errorf("failed to close SSE stream body: %w", err)
+			if t.ctx.Err() != nil {
 				return
 			}
-		}()
-
-		t.readSSE(resp.Body)
-
-		close(t.sseConnectClose)
+			resp = t.reconnect()
+		}
 	}()
 
 	// Wait for the endpoint to be received
@@ -133,18 +159,99 @@ func (t *sseClientTransport) Start() error {
 		return fmt.Errorf("timeout waiting for endpoint")
 	}
 
+	t.notifyState(ConnectionStateConnected)
+	return nil
+}
+
+// connect opens the SSE stream, resuming the session of lastEventID if not empty
+func (t *sseClientTransport) connect(lastEventID string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.serverURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Connection", "keep-alive")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := t.client.Do(req) //nolint:bodyclose
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSE stream: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	return resp, nil
+}
+
+// reconnect opens the SSE stream again with exponential backoff, it returns nil
+// if the transport is closed or the attempts are exhausted.
+func (t *sseClientTransport) reconnect() *http.Response {
+	if t.maxReconnectAttempts <= 0 {
+		t.notifyState(ConnectionStateDisconnected)
+		return nil
+	}
+	t.notifyState(ConnectionStateReconnecting)
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	delay := t.reconnectInitialDelay
+
+	for attempt := 1; attempt <= t.maxReconnectAttempts; attempt++ {
+		// full jitter on the upper half, so that clients dropped together do not reconnect together
+		wait := delay/2 + time.Duration(random.Int63n(int64(delay/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-t.ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		resp, err := t.connect(t.getLastEventID())
+		if err == nil {
+			return resp
+		}
+		if t.ctx.Err() != nil {
+			return nil
+		}
+		t.logger.Warnf("sse reconnect attempt %d/%d failed: %v", attempt, t.maxReconnectAttempts, err)
+
+		if delay *= 2; delay > t.reconnectMaxDelay {
+			delay = t.reconnectMaxDelay
+		}
+	}
+
+	t.logger.Errorf("sse reconnect gave up after %d attempts", t.maxReconnectAttempts)
+	t.notifyState(ConnectionStateDisconnected)
 	return nil
 }
 
 // readSSE continuously reads the SSE stream and processes events.
 // It runs until the connection is closed or an error occurs.
 func (t *sseClientTransport) readSSE(reader io.ReadCloser) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer pkg.Recover()
+
+		select {
+		case <-t.ctx.Done():
+			_ = reader.Close()
+		case <-done:
+		}
+	}()
 	defer func() {
 		_ = reader.Close()
 	}()
 
 	br := bufio.NewReader(reader)
-	var event, data string
+	var event, data, id string
 
 	for {
 		line, err := br.ReadString('\n')
@@ -174,6 +281,11 @@ func (t *sseClientTransport) readSSE(reader io.ReadCloser) {
 				event = ""
 				data = ""
 			}
+			// the id is kept once the event is processed, so that a reconnection does not skip it
+			if id != "" {
+				t.setLastEventID(id)
+				id = ""
+			}
 			continue
 		}
 
@@ -181,6 +293,8 @@ func (t *sseClientTransport) readSSE(reader io.ReadCloser) {
 			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
 		} else if strings.HasPrefix(line, "data:") {
 			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		} else if strings.HasPrefix(line, "id:") {
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
 		}
 	}
 }
@@ -196,8 +310,24 @@ func (t *sseClientTransport) handleSSEEvent(event, data string) {
 			return
 		}
 		t.logger.Debugf("Received endpoint: %s", endpoint.String())
+
+		t.mu.Lock()
+		previous := t.messageEndpoint
 		t.messageEndpoint = endpoint
-		close(t.endpointChan)
+		if previous != nil && previous.String() != endpoint.String() {
+			// the event ids of the lost session are meaningless for the new one
+			t.lastEventID = ""
+		}
+		t.mu.Unlock()
+
+		switch {
+		case previous == nil:
+			t.endpointOnce.Do(func() { close(t.endpointChan) })
+		case previous.String() == endpoint.String():
+			t.notifyState(ConnectionStateReconnected)
+		default:
+			t.notifyState(ConnectionStateSessionLost)
+		}
 	case "message":
 		ctx, cancel := context.WithTimeout(t.ctx, t.receiveTimeout)
 		defer cancel()
@@ -209,7 +339,11 @@ func (t *sseClientTransport) handleSSEEvent(event, data string) {
 }
 
 func (t *sseClientTransport) Send(ctx context.Context, msg Message) error {
-	t.logger.Debugf("Sending message: %s to %s", msg, t.messageEndpoint.String())
+	t.mu.RLock()
+	messageEndpoint := t.messageEndpoint
+	t.mu.RUnlock()
+
+	t.logger.Debugf("Sending message: %s to %s", msg, messageEndpoint.String())
 
 	var (
 		err  error
@@ -217,7 +351,7 @@ func (t *sseClientTransport) Send(ctx context.Context, msg Message) error {
 		resp *http.Response
 	)
 
-	req, err = http.NewRequestWithContext(ctx, http.MethodPost, t.messageEndpoint.String(), bytes.NewReader(msg))
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, messageEndpoint.String(), bytes.NewReader(msg))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -240,6 +374,39 @@ func (t *sseClientTransport) SetReceiver(receiver clientReceiver) {
 	t.receiver = receiver
 }
 
+func (t *sseClientTransport) AddConnectionStateHandler(handler func(state ConnectionState)) {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+
+	t.stateHandlers = append(t.stateHandlers, handler)
+}
+
+func (t *sseClientTransport) notifyState(state ConnectionState) {
+	t.logger.Debugf("sse connection state: %s", state)
+
+	t.stateMu.Lock()
+	handlers := append([]func(ConnectionState){}, t.stateHandlers...)
+	t.stateMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(state)
+	}
+}
+
+func (t *sseClientTransport) getLastEventID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.lastEventID
+}
+
+func (t *sseClientTransport) setLastEventID(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastEventID = id
+}
+
 func (t *sseClientTransport) Close() error {
 	t.cancel()
 
@@ -1,8 +1,12 @@
 package transport
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -73,3 +77,113 @@ func Test_sseClientTransport_handleSSEEvent(t1 *testing.T) {
 		})
 	}
 }
+
+func TestSSEClientReconnect(t *testing.T) {
+	svr, handler, err := NewSSEServerTransportAndHandler("/message", WithSSEServerTransportAndHandlerOptionReconnectTimeout(30*time.Second))
+	if err != nil {
+		t.Fatalf("NewSSEServerTransportAndHandler failed: %v", err)
+	}
+	sessionManager := newMockSessionManager()
+	svr.SetSessionManager(sessionManager)
+
+	mux := http.NewServeMux()
+	mux.Handle("/sse", handler.HandleSSE())
+	mux.Handle("/message", handler.HandleMessage())
+	httpSvr := httptest.NewServer(mux)
+	defer httpSvr.Close()
+
+	states := make(chan ConnectionState, 10)
+	received := make(chan string, 10)
+	client, err := NewSSEClientTransport(httpSvr.URL+"/sse",
+		WithSSEClientOptionReconnectBackoff(10*time.Millisecond, 50*time.Millisecond),
+		WithSSEClientOptionConnectionStateHandler(func(state ConnectionState) { states <- state }))
+	if err != nil {
+		t.Fatalf("NewSSEClientTransport failed: %v", err)
+	}
+	client.SetReceiver(ClientReceiverF(func(_ context.Context, msg []byte) error {
+		received <- string(msg)
+		return nil
+	}))
+	if err = client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer client.Close()
+
+	expectStates := func(want ...ConnectionState) {
+		for _, w := range want {
+			select {
+			case got := <-states:
+				if got != w {
+					t.Fatalf("state got %s, want %s", got, w)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("state %s not reported", w)
+			}
+		}
+	}
+	expectMessage := func(sessionID, msg string) {
+		go func() {
+			if err := svr.Send(context.Background(), sessionID, Message(msg)); err != nil {
+				t.Errorf("send failed: %v", err)
+			}
+		}()
+		select {
+		case got := <-received:
+			if got != msg {
+				t.Fatalf("received got %s, want %s", got, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %s not received", msg)
+		}
+	}
+	sessionID := func() string {
+		c := client.(*sseClientTransport)
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.messageEndpoint.Query().Get("sessionID")
+	}
+
+	expectStates(ConnectionStateConnected)
+	firstSessionID := sessionID()
+	expectMessage(firstSessionID, "msg1")
+
+	// a dropped connection resumes the session
+	httpSvr.CloseClientConnections()
+	expectStates(ConnectionStateReconnecting, ConnectionStateReconnected)
+	if sessionID() != firstSessionID {
+		t.Fatalf("session id got %s, want %s", sessionID(), firstSessionID)
+	}
+	expectMessage(firstSessionID, "msg2")
+
+	// a session closed by the server is replaced by a new one
+	sessionManager.CloseSession(firstSessionID)
+	expectStates(ConnectionStateReconnecting, ConnectionStateSessionLost)
+	if sessionID() == firstSessionID {
+		t.Fatalf("session id not renewed")
+	}
+	expectMessage(sessionID(), "msg3")
+}
+
+func TestSSEClientReconnectBackoffValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		initialDelay time.Duration
+		maxDelay     time.Duration
+		expectedErr  bool
+	}{
+		{name: "test_valid", initialDelay: 10 * time.Millisecond, maxDelay: time.Second},
+		{name: "test_equal_delays", initialDelay: time.Second, maxDelay: time.Second},
+		{name: "test_zero_initial_delay", initialDelay: 0, maxDelay: time.Second, expectedErr: true},
+		{name: "test_negative_initial_delay", initialDelay: -time.Second, maxDelay: time.Second, expectedErr: true},
+		{name: "test_max_below_initial", initialDelay: time.Second, maxDelay: time.Millisecond, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSSEClientTransport("http://127.0.0.1/sse", WithSSEClientOptionReconnectBackoff(tt.initialDelay, tt.maxDelay))
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("NewSSEClientTransport error: got %v, want error %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
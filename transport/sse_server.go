@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"io"
@@ -17,6 +18,8 @@ import (
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
+const defaultSSEReplayBufferSize = 100
+
 type SSEServerTransportOption func(*sseServerTransport)
 
 func WithSSEServerTransportOptionLogger(logger pkg.Logger) SSEServerTransportOption {
@@ -43,6 +46,24 @@ func WithSSEServerTransportOptionURLPrefix(urlPrefix string) SSEServerTransportO
 	}
 }
 
+// WithSSEServerTransportOptionReplayBufferSize sets the number of recent events of a session kept
+// to be replayed to a client reconnecting with Last-Event-ID, 0 disables the replay.
+// A client that missed more events than kept gets a new session instead of resuming.
+func WithSSEServerTransportOptionReplayBufferSize(size int) SSEServerTransportOption {
+	return func(t *sseServerTransport) {
+		t.replayBufferSize = size
+	}
+}
+
+// WithSSEServerTransportOptionReconnectTimeout sets how long a session outlives its dropped SSE connection,
+// waiting for the client to reconnect with Last-Event-ID. The default 0 closes the session as soon as the connection drops,
+// so that sessions are only resumed when enabled.
+func WithSSEServerTransportOptionReconnectTimeout(timeout time.Duration) SSEServerTransportOption {
+	return func(t *sseServerTransport) {
+		t.reconnectTimeout = timeout
+	}
+}
+
 type SSEServerTransportAndHandlerOption func(*sseServerTransport)
 
 func WithSSEServerTransportAndHandlerOptionLogger(logger pkg.Logger) SSEServerTransportAndHandlerOption {
@@ -51,6 +72,18 @@ func WithSSEServerTransportAndHandlerOptionLogger(logger pkg.Logger) SSEServerTr
 	}
 }
 
+func WithSSEServerTransportAndHandlerOptionReplayBufferSize(size int) SSEServerTransportAndHandlerOption {
+	return func(t *sseServerTransport) {
+		t.replayBufferSize = size
+	}
+}
+
+func WithSSEServerTransportAndHandlerOptionReconnectTimeout(timeout time.Duration) SSEServerTransportAndHandlerOption {
+	return func(t *sseServerTransport) {
+		t.reconnectTimeout = timeout
+	}
+}
+
 type sseServerTransport struct {
 	// ctx is the context that controls the lifecycle of the SSE server.
 	// It is used to coordinate cancellation of all ongoing send operations when the server is shutting down.
@@ -69,11 +102,16 @@ type sseServerTransport struct {
 
 	sessionManager sessionManager
 
+	// event ids and replay buffers of the sessions
+	streams pkg.SyncMap[*sseStream]
+
 	// options
-	logger      pkg.Logger
-	ssePath     string
-	messagePath string
-	urlPrefix   string
+	logger           pkg.Logger
+	ssePath          string
+	messagePath      string
+	urlPrefix        string
+	replayBufferSize int
+	reconnectTimeout time.Duration
 }
 
 type SSEHandler struct {
@@ -100,12 +138,13 @@ func NewSSEServerTransport(addr string, opts ...SSEServerTransportOption) (Serve
 	ctx, cancel := context.WithCancel(context.Background())
 
 	t := &sseServerTransport{
-		ctx:         ctx,
-		cancel:      cancel,
-		logger:      pkg.DefaultLogger,
-		ssePath:     "/sse",
-		messagePath: "/message",
-		urlPrefix:   "",
+		ctx:              ctx,
+		cancel:           cancel,
+		logger:           pkg.DefaultLogger,
+		ssePath:          "/sse",
+		messagePath:      "/message",
+		urlPrefix:        "",
+		replayBufferSize: defaultSSEReplayBufferSize,
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -157,6 +196,7 @@ func NewSSEServerTransportAndHandler(messageEndpointURL string,
 		cancel:             cancel,
 		messageEndpointURL: messageEndpointURL,
 		logger:             pkg.DefaultLogger,
+		replayBufferSize:   defaultSSEReplayBufferSize,
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -215,12 +255,42 @@ func (t *sseServerTransport) handleSSE(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 		return
 	}
+
+	// Resume the session of the dropped connection, or create an SSE connection
+	var (
+		conn   *sseConn
+		replay []sseEvent
+	)
+	sessionID, stream, lastID := t.resumeSession(r.Header.Get("Last-Event-ID"))
+	if stream != nil {
+		requestCtx, conn = stream.connect(r.Context())
+
+		// the events are taken after the previous connection has stopped, so none is missed
+		var complete bool
+		if replay, complete = stream.eventsAfter(*lastID); !complete {
+			t.logger.Warnf("sse reconnect after events no longer kept, a new session is created: sessionID=%s", sessionID)
+			stream.disconnect(conn, 0, func() { t.closeSession(sessionID) })
+			stream = nil
+		}
+	}
+	if stream == nil {
+		sessionID = uuid.New().String()
+		stream = newSSEStream(t.replayBufferSize)
+		t.streams.Store(sessionID, stream)
+		t.sessionManager.CreateSession(sessionID)
+		requestCtx, conn = stream.connect(r.Context())
+		replay = nil
+	}
 	w.WriteHeader(http.StatusOK)
 
-	// Create an SSE connection
-	sessionID := uuid.New().String()
-	t.sessionManager.CreateSession(sessionID)
-	defer t.sessionManager.CloseSession(sessionID)
+	sessionClosed := false
+	defer func() {
+		timeout := t.reconnectTimeout
+		if sessionClosed {
+			timeout = 0
+		}
+		stream.disconnect(conn, timeout, func() { t.closeSession(sessionID) })
+	}()
 
 	uri := fmt.Sprintf("%s?sessionID=%s", t.messageEndpointURL, sessionID)
 	// Send the initial endpoint event
@@ -230,10 +300,25 @@ func (t *sseServerTransport) handleSSE(w http.ResponseWriter, r *http.Request) {
 	}
 	flusher.Flush()
 
+	for _, event := range replay {
+		if err := t.writeSSEEvent(w, sessionID, stream, event); err != nil {
+			t.logger.Errorf("Failed to replay message: %v", err)
+			return
+		}
+		flusher.Flush()
+	}
+
 	for {
+		// superseded by a reconnection, which may no longer wait for this connection
+		if requestCtx.Err() != nil {
+			return
+		}
+
 		msg, err := t.sessionManager.GetMessageForSend(requestCtx, sessionID)
 		if err != nil {
-			if !errors.Is(err, pkg.ErrSendEOF) {
+			if errors.Is(err, pkg.ErrSendEOF) || errors.Is(err, pkg.ErrLackSession) {
+				sessionClosed = true
+			} else {
 				t.logger.Debugf("sse connect request err: %+v, sessionID=%s", err.Error(), sessionID)
 			}
 			return
@@ -241,14 +326,51 @@ func (t *sseServerTransport) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 		t.logger.Debugf("Sending message: %s", string(msg))
 
-		if _, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+		// kept before being written, so that it can be replayed if the connection turns out to be broken
+		event := sseEvent{id: stream.append(msg), data: msg}
+		if err = t.writeSSEEvent(w, sessionID, stream, event); err != nil {
 			t.logger.Errorf("Failed to write message: %v", err)
-			continue
+			return
 		}
 		flusher.Flush()
 	}
 }
 
+// resumeSession finds the session of the event id sent by a reconnecting client,
+// and the id of the last event it received. stream is nil if the session can not be resumed.
+func (t *sseServerTransport) resumeSession(lastEventID string) (sessionID string, stream *sseStream, lastID *uint64) {
+	if lastEventID == "" {
+		return "", nil, nil
+	}
+
+	sessionID, resumeToken, id, err := parseSSEEventID(lastEventID)
+	if err != nil {
+		t.logger.Debugf("sse reconnect with invalid Last-Event-ID: %v", err)
+		return "", nil, nil
+	}
+
+	stream, ok := t.streams.Load(sessionID)
+	if !ok || !t.sessionManager.IsExistSession(sessionID) {
+		t.logger.Debugf("sse reconnect to unknown session, a new session is created: sessionID=%s", sessionID)
+		return "", nil, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(resumeToken), []byte(stream.resumeToken)) != 1 {
+		t.logger.Warnf("sse reconnect with a wrong resume token, a new session is created: sessionID=%s", sessionID)
+		return "", nil, nil
+	}
+	return sessionID, stream, &id
+}
+
+func (t *sseServerTransport) writeSSEEvent(w io.Writer, sessionID string, stream *sseStream, event sseEvent) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", formatSSEEventID(sessionID, stream.resumeToken, event.id), event.data)
+	return err
+}
+
+func (t *sseServerTransport) closeSession(sessionID string) {
+	t.streams.Delete(sessionID)
+	t.sessionManager.CloseSession(sessionID)
+}
+
 // handleMessage processes incoming JSON-RPC messages from clients and sends responses
 // back through both the SSE connection and HTTP response.
 func (t *sseServerTransport) handleMessage(w http.ResponseWriter, r *http.Request) {
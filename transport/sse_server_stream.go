@@ -0,0 +1,161 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sseConnectTimeout bounds the wait for a superseded connection to stop, a write blocked on a dropped connection
+// not being interrupted by the cancellation
+var sseConnectTimeout = 5 * time.Second
+
+// sseStream keeps the recent events of a session, so that a client reconnecting with Last-Event-ID
+// resumes the session and gets the events it may have missed.
+type sseStream struct {
+	// resumeToken is part of the event ids, so that only the client having read the stream can resume it,
+	// while the session id is also known from the message endpoint URL
+	resumeToken string
+
+	mu sync.Mutex
+
+	nextID uint64
+	// recent events, oldest first, at most replayBufferSize
+	events           []sseEvent
+	replayBufferSize int
+
+	// the connection currently reading the stream, superseded by a reconnection
+	conn *sseConn
+	// closes the session when the client does not reconnect in time
+	closeTimer *time.Timer
+}
+
+type sseConn struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type sseEvent struct {
+	id   uint64
+	data []byte
+}
+
+func newSSEStream(replayBufferSize int) *sseStream {
+	return &sseStream{resumeToken: uuid.New().String(), replayBufferSize: replayBufferSize}
+}
+
+// connect makes the connection of ctx the one reading the stream. A previous connection, whose drop
+// may not have been noticed yet, is canceled and waited for, so that it no longer takes messages.
+// The wait is given up after sseConnectTimeout: the previous connection is then blocked writing an event,
+// which is already kept for replay, and takes no further message once canceled.
+func (s *sseStream) connect(ctx context.Context) (context.Context, *sseConn) {
+	ctx, cancel := context.WithCancel(ctx)
+	conn := &sseConn{cancel: cancel, done: make(chan struct{})}
+
+	s.mu.Lock()
+	previous := s.conn
+	s.conn = conn
+	if s.closeTimer != nil {
+		s.closeTimer.Stop()
+		s.closeTimer = nil
+	}
+	s.mu.Unlock()
+
+	if previous != nil {
+		previous.cancel()
+
+		timer := time.NewTimer(sseConnectTimeout)
+		defer timer.Stop()
+		select {
+		case <-previous.done:
+		case <-timer.C:
+		}
+	}
+	return ctx, conn
+}
+
+// disconnect calls closeSession after timeout unless the client reconnects in the meantime,
+// a timeout <= 0 closes the session at once.
+func (s *sseStream) disconnect(conn *sseConn, timeout time.Duration, closeSession func()) {
+	conn.cancel()
+	defer close(conn.done)
+
+	s.mu.Lock()
+	if s.conn != conn {
+		// superseded by a reconnection
+		s.mu.Unlock()
+		return
+	}
+	s.conn = nil
+	if timeout > 0 {
+		s.closeTimer = time.AfterFunc(timeout, func() {
+			s.mu.Lock()
+			reconnected := s.conn != nil
+			s.mu.Unlock()
+
+			if !reconnected {
+				closeSession()
+			}
+		})
+	}
+	s.mu.Unlock()
+
+	if timeout <= 0 {
+		closeSession()
+	}
+}
+
+// append assigns the next event id to data and keeps it for replay
+func (s *sseStream) append(data []byte) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	if s.replayBufferSize > 0 {
+		if len(s.events) == s.replayBufferSize {
+			s.events = s.events[1:]
+		}
+		s.events = append(s.events, sseEvent{id: s.nextID, data: data})
+	}
+	return s.nextID
+}
+
+// eventsAfter returns the events whose id is greater than lastID,
+// false when some of them are no longer kept, or lastID was never sent
+func (s *sseStream) eventsAfter(lastID uint64) ([]sseEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case lastID == s.nextID:
+		return nil, true
+	case lastID > s.nextID:
+		return nil, false
+	}
+	for i, event := range s.events {
+		if event.id > lastID {
+			return append([]sseEvent{}, s.events[i:]...), event.id == lastID+1
+		}
+	}
+	return nil, false
+}
+
+func formatSSEEventID(sessionID string, resumeToken string, id uint64) string {
+	return fmt.Sprintf("%s:%s:%d", sessionID, resumeToken, id)
+}
+
+func parseSSEEventID(eventID string) (sessionID string, resumeToken string, id uint64, err error) {
+	parts := strings.Split(eventID, ":")
+	if len(parts) != 3 {
+		return "", "", 0, fmt.Errorf("invalid event id %q", eventID)
+	}
+	if id, err = strconv.ParseUint(parts[2], 10, 64); err != nil {
+		return "", "", 0, fmt.Errorf("invalid event id %q: %w", eventID, err)
+	}
+	return parts[0], parts[1], id, nil
+}
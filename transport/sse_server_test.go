@@ -1,8 +1,15 @@
 package transport
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_joinPath(t *testing.T) {
@@ -53,3 +60,184 @@ func Test_joinPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSSEServerResume(t *testing.T) {
+	svr, handler, err := NewSSEServerTransportAndHandler("/message",
+		WithSSEServerTransportAndHandlerOptionReconnectTimeout(time.Second), WithSSEServerTransportAndHandlerOptionReplayBufferSize(2))
+	if err != nil {
+		t.Fatalf("NewSSEServerTransportAndHandler failed: %v", err)
+	}
+	svr.SetSessionManager(newMockSessionManager())
+
+	httpSvr := httptest.NewServer(handler.HandleSSE())
+	defer httpSvr.Close()
+
+	connect := func(lastEventID string) (*http.Response, *bufio.Reader) {
+		req, err := http.NewRequest(http.MethodGet, httpSvr.URL, nil)
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Do request failed: %v", err)
+		}
+		return resp, bufio.NewReader(resp.Body)
+	}
+	// readEvent returns the id and data of the next event
+	readEvent := func(reader *bufio.Reader) (string, string) {
+		var id, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read stream failed: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "":
+				return id, data
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	resp, reader := connect("")
+	_, endpoint := readEvent(reader)
+	sessionID := strings.TrimPrefix(endpoint, "/message?sessionID=")
+
+	go func() {
+		if err := svr.Send(context.Background(), sessionID, Message("msg1")); err != nil {
+			t.Errorf("send failed: %v", err)
+		}
+	}()
+	id, data := readEvent(reader)
+	if !strings.HasPrefix(id, sessionID+":") || !strings.HasSuffix(id, ":1") || data != "msg1" {
+		t.Fatalf("event got id=%s data=%s, want id=%s:<token>:1 data=msg1", id, data, sessionID)
+	}
+	eventIDPrefix := strings.TrimSuffix(id, "1")
+	_ = resp.Body.Close()
+
+	// sent while the client is disconnected
+	go func() {
+		if err := svr.Send(context.Background(), sessionID, Message("msg2")); err != nil {
+			t.Errorf("send failed: %v", err)
+		}
+	}()
+
+	// knowing the session id is not enough to resume it
+	resp, reader = connect(sessionID + ":wrong:0")
+	if _, otherEndpoint := readEvent(reader); otherEndpoint == endpoint {
+		t.Fatalf("resumed with a wrong token")
+	}
+	_ = resp.Body.Close()
+
+	// the client missed msg1, so it reconnects with the event id before it
+	resp, reader = connect(eventIDPrefix + "0")
+	if _, resumedEndpoint := readEvent(reader); resumedEndpoint != endpoint {
+		t.Fatalf("endpoint got %s, want %s", resumedEndpoint, endpoint)
+	}
+	for i, want := range []string{"msg1", "msg2"} {
+		wantID := fmt.Sprintf("%s%d", eventIDPrefix, i+1)
+		if id, data := readEvent(reader); id != wantID || data != want {
+			t.Fatalf("event got id=%s data=%s, want id=%s data=%s", id, data, wantID, want)
+		}
+	}
+	_ = resp.Body.Close()
+
+	// msg1 is no longer kept once msg3 is sent, so the client that missed it gets a new session
+	go func() {
+		if err := svr.Send(context.Background(), sessionID, Message("msg3")); err != nil {
+			t.Errorf("send failed: %v", err)
+		}
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, complete := mustLoadSSEStream(t, svr, sessionID).eventsAfter(0); !complete {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("msg1 still kept")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp, reader = connect(eventIDPrefix + "0")
+	defer resp.Body.Close()
+	if _, newEndpoint := readEvent(reader); newEndpoint == endpoint {
+		t.Fatalf("resumed after missed events no longer kept")
+	}
+}
+
+func TestSSEServerDropClosesSession(t *testing.T) {
+	// resumption is not enabled by default
+	svr, handler, err := NewSSEServerTransportAndHandler("/message")
+	if err != nil {
+		t.Fatalf("NewSSEServerTransportAndHandler failed: %v", err)
+	}
+	sessionManager := newMockSessionManager()
+	svr.SetSessionManager(sessionManager)
+
+	httpSvr := httptest.NewServer(handler.HandleSSE())
+	defer httpSvr.Close()
+
+	resp, err := http.Get(httpSvr.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	reader := bufio.NewReader(resp.Body)
+	line := ""
+	for !strings.HasPrefix(line, "data: ") {
+		if line, err = reader.ReadString('\n'); err != nil {
+			t.Fatalf("read stream failed: %v", err)
+		}
+	}
+	sessionID := strings.TrimSpace(strings.TrimPrefix(line, "data: /message?sessionID="))
+	if !sessionManager.IsExistSession(sessionID) {
+		t.Fatalf("session %s not created", sessionID)
+	}
+	_ = resp.Body.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for sessionManager.IsExistSession(sessionID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("session still open after its stream dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func mustLoadSSEStream(t *testing.T, svr ServerTransport, sessionID string) *sseStream {
+	stream, ok := svr.(*sseServerTransport).streams.Load(sessionID)
+	if !ok {
+		t.Fatalf("stream of session %s not found", sessionID)
+	}
+	return stream
+}
+
+func TestSSEStreamConnectTimeout(t *testing.T) {
+	timeout := sseConnectTimeout
+	sseConnectTimeout = 50 * time.Millisecond
+	defer func() { sseConnectTimeout = timeout }()
+
+	stream := newSSEStream(1)
+	previousCtx, _ := stream.connect(context.Background())
+
+	// the previous connection never stops, as when blocked writing to a dropped connection
+	connected := make(chan struct{})
+	go func() {
+		stream.connect(context.Background())
+		close(connected)
+	}()
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Fatalf("connect blocked by the previous connection")
+	}
+	if previousCtx.Err() == nil {
+		t.Fatalf("previous connection not canceled")
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -26,18 +29,65 @@ func WithStdioClientOptionEnv(env ...string) StdioClientTransportOption {
 	}
 }
 
+// WithStdioClientOptionStderrHandler sets handler to be called with each line the server process writes to its standard error
+func WithStdioClientOptionStderrHandler(handler func(line string)) StdioClientTransportOption {
+	return func(t *stdioClientTransport) {
+		t.stderrHandler = handler
+	}
+}
+
+// WithStdioClientOptionStderrToLogger writes the lines of the standard error of the server process to the logger
+func WithStdioClientOptionStderrToLogger() StdioClientTransportOption {
+	return func(t *stdioClientTransport) {
+		t.stderrHandler = func(line string) {
+			t.logger.Infof("server stderr: %s", line)
+		}
+	}
+}
+
 const mcpMessageDelimiter = '\n'
 
+// stdioMaxMessageSize is the size of the longest message read from the output of the server process
+const stdioMaxMessageSize = 32 << 20
+
+// stdioExitGracePeriod is how long the output of the server process is still read after its exit,
+// a child process it started may hold the pipes open for longer
+const stdioExitGracePeriod = time.Second
+
+// stdioStderrTailLines is the number of the last lines of stderr kept to explain the exit of the server process
+const stdioStderrTailLines = 20
+
 type stdioClientTransport struct {
 	cmd      *exec.Cmd
 	receiver clientReceiver
 	reader   io.Reader
 	writer   io.WriteCloser
+	stderr   io.Reader
+
+	// the read ends of the stdout and stderr pipes, and the write ends passed to the process
+	readPipes  []*os.File
+	writePipes []*os.File
+
+	logger        pkg.Logger
+	stderrHandler func(line string)
+
+	// the last lines of stderr, written by the stderr reader only and read once it is done
+	stderrTail []string
+
+	stateMu       sync.Mutex
+	stateHandlers []func(state ConnectionState)
 
-	logger pkg.Logger
+	// exited is closed once the server process exited, with exitErr set
+	exited  chan struct{}
+	exitErr error
+	closing *pkg.AtomicBool
+
+	// started is set once the process is started, the goroutines closing exited only run then
+	started bool
 
 	cancel          context.CancelFunc
 	receiveShutDone chan struct{}
+	stderrShutDone  chan struct{}
 }
 
 func NewStdioClientTransport(command string, args []string, opts ...StdioClientTransportOption) (ClientTransport, error) {
@@ -50,17 +100,34 @@ func NewStdioClientTransport(command string, args []string, opts ...StdioClientT
 		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	// unlike those of cmd.StdoutPipe, these pipes are not closed by cmd.Wait,
+	// so that the exit of the process can be waited for while its output is still being read
+	stdout, stdoutWriter, err := os.Pipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
+	cmd.Stdout = stdoutWriter
+
+	stderr, stderrWriter, err := os.Pipe()
+	if err != nil {
+		_ = stdout.Close()
+		_ = stdoutWriter.Close()
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	cmd.Stderr = stderrWriter
 
 	t := &stdioClientTransport{
 		cmd:             cmd,
 		reader:          stdout,
 		writer:          stdin,
+		stderr:          stderr,
+		readPipes:       []*os.File{stdout, stderr},
+		writePipes:      []*os.File{stdoutWriter, stderrWriter},
 		logger:          pkg.DefaultLogger,
+		exited:          make(chan struct{}),
+		closing:         pkg.NewAtomicBool(),
 		receiveShutDone: make(chan struct{}),
+		stderrShutDone:  make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -70,10 +137,20 @@ func NewStdioClientTransport(command string, args []string, opts ...StdioClientT
 }
 
 func (t *stdioClientTransport) Start() error {
-	if err := t.cmd.Start(); err != nil {
+	err := t.cmd.Start()
+	// the process has its own copy of the write ends, closing them here lets the reads end with its output
+	for _, pipe := range t.writePipes {
+		_ = pipe.Close()
+	}
+	if err != nil {
+		for _, pipe := range t.readPipes {
+			_ = pipe.Close()
+		}
 		return fmt.Errorf("failed to start command: %w", err)
 	}
 
+	t.started = true
+
 	innerCtx, cancel := context.WithCancel(context.Background())
 	t.cancel = cancel
 
@@ -84,53 +161,170 @@ func (t *stdioClientTransport) Start() error {
 		close(t.receiveShutDone)
 	}()
 
+	go func() {
+		defer pkg.Recover()
+		defer close(t.stderrShutDone)
+
+		t.readStderr()
+	}()
+
+	go func() {
+		defer pkg.Recover()
+
+		t.wait()
+	}()
+
 	return nil
 }
 
 func (t *stdioClientTransport) Send(_ context.Context, msg Message) error {
+	select {
+	case <-t.exited:
+		return t.ConnectionError()
+	default:
+	}
+
 	_, err := t.writer.Write(append(msg, mcpMessageDelimiter))
 	return err
 }
 
+func (t *stdioClientTransport) AddConnectionStateHandler(handler func(state ConnectionState)) {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+
+	t.stateHandlers = append(t.stateHandlers, handler)
+}
+
+// ConnectionError returns a *pkg.ProcessExitError once the server process exited
+func (t *stdioClientTransport) ConnectionError() error {
+	select {
+	case <-t.exited:
+	default:
+		return nil
+	}
+
+	exitCode := -1
+	if t.cmd.ProcessState != nil {
+		exitCode = t.cmd.ProcessState.ExitCode()
+	}
+	return &pkg.ProcessExitError{
+		ExitCode: exitCode,
+		Stderr:   strings.Join(t.stderrTail, "\n"),
+		Err:      t.exitErr,
+	}
+}
+
 func (t *stdioClientTransport) SetReceiver(receiver clientReceiver) {
 	t.receiver = receiver
 }
 
 func (t *stdioClientTransport) Close() error {
+	t.closing.Store(true)
+
+	if !t.started {
+		// no process to wait for, the pipes were created for nothing
+		for _, pipe := range append(t.readPipes, t.writePipes...) {
+			_ = pipe.Close()
+		}
+		// a failed start already closed it
+		if err := t.writer.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			return fmt.Errorf("failed to close writer: %w", err)
+		}
+		return nil
+	}
+
 	t.cancel()
 
 	if err := t.writer.Close(); err != nil {
 		return fmt.Errorf("failed to close writer: %w", err)
 	}
 
-	if err := t.cmd.Wait(); err != nil {
-		return err
+	<-t.exited
+
+	return t.exitErr
+}
+
+// wait waits for the exit of the server process, then for the rest of its output to be read,
+// and reports the loss of the connection unless the transport is being closed.
+func (t *stdioClientTransport) wait() {
+	t.exitErr = t.cmd.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), stdioExitGracePeriod)
+	defer cancel()
+	for _, done := range []chan struct{}{t.receiveShutDone, t.stderrShutDone} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
 	}
+	// ends the reads of the pipes still held by a child process, stderr being read until then for the tail of the error
+	for _, pipe := range t.readPipes {
+		_ = pipe.Close()
+	}
+	<-t.stderrShutDone
 
-	<-t.receiveShutDone
+	close(t.exited)
 
-	return nil
+	if t.closing.Load() {
+		return
+	}
+
+	t.logger.Errorf("stdio client transport: %v", t.ConnectionError())
+
+	t.stateMu.Lock()
+	handlers := append([]func(ConnectionState){}, t.stateHandlers...)
+	t.stateMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(ConnectionStateDisconnected)
+	}
+}
+
+// readStderr reads the standard error of the server process, keeping its last lines
+func (t *stdioClientTransport) readStderr() {
+	s := bufio.NewScanner(t.stderr)
+
+	for s.Scan() {
+		line := s.Text()
+
+		if len(t.stderrTail) == stdioStderrTailLines {
+			t.stderrTail = t.stderrTail[1:]
+		}
+		t.stderrTail = append(t.stderrTail, line)
+
+		if t.stderrHandler != nil {
+			t.stderrHandler(line)
+		}
+	}
+
+	if err := s.Err(); err != nil && !errors.Is(err, os.ErrClosed) {
+		t.logger.Warnf("client read stderr error: %v", err)
+		// drained so that the server process does not block writing to it
+		_, _ = io.Copy(io.Discard, t.stderr)
+	}
 }
 
+// receive reads the output of the server process until its end, even once the messages are no longer handled,
+// so that the process does not block writing to it
 func (t *stdioClientTransport) receive(ctx context.Context) {
 	s := bufio.NewScanner(t.reader)
+	s.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), stdioMaxMessageSize)
 
 	for s.Scan() {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			if err := t.receiver.Receive(ctx, s.Bytes()); err != nil {
-				t.logger.Errorf("receiver failed: %v", err)
-				return
-			}
+		if ctx.Err() != nil {
+			continue
+		}
+		if err := t.receiver.Receive(ctx, s.Bytes()); err != nil {
+			t.logger.Errorf("receiver failed: %v", err)
 		}
 	}
 
 	if err := s.Err(); err != nil {
-		if !errors.Is(err, io.ErrClosedPipe) { // This error occurs during unit tests, suppressing it here
-			t.logger.Errorf("client receive unexpected error reading input: %v", err)
+		// the pipe is closed by wait when a child process holds it, or by the unit tests
+		if errors.Is(err, io.ErrClosedPipe) || errors.Is(err, os.ErrClosed) {
+			return
 		}
-		return
+		t.logger.Errorf("client receive unexpected error reading input: %v", err)
+		_, _ = io.Copy(io.Discard, t.reader)
 	}
 }
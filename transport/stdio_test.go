@@ -1,6 +1,8 @@
 package transport
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -10,6 +12,8 @@ import (
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
 type mock struct {
@@ -80,6 +84,56 @@ func TestStdioTransport(t *testing.T) {
 	testTransport(t, client, server)
 }
 
+func TestStdioClientProcessExit(t *testing.T) {
+	lines := make(chan string, 2)
+	clientT, err := NewStdioClientTransport("sh", []string{"-c", "echo starting >&2; read line; echo $line failed >&2; exit 3"},
+		WithStdioClientOptionStderrHandler(func(line string) {
+			lines <- line
+		}))
+	if err != nil {
+		t.Fatalf("NewStdioClientTransport failed: %v", err)
+	}
+	clientT.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+
+	states := make(chan ConnectionState, 1)
+	clientT.(ConnectionStateNotifier).AddConnectionStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+
+	if err = clientT.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if line := <-lines; line != "starting" {
+		t.Fatalf("stderr line got %s, want starting", line)
+	}
+	if err = clientT.Send(context.Background(), Message("request")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	select {
+	case state := <-states:
+		if state != ConnectionStateDisconnected {
+			t.Fatalf("connection state got %s, want %s", state, ConnectionStateDisconnected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process exit not detected")
+	}
+
+	var exitErr *pkg.ProcessExitError
+	err = clientT.(ConnectionErrorReporter).ConnectionError()
+	if !errors.As(err, &exitErr) || !errors.Is(err, pkg.ErrConnectionLost) {
+		t.Fatalf("connection error got %v, want *pkg.ProcessExitError", err)
+	}
+	if exitErr.ExitCode != 3 || exitErr.Stderr != "starting\nrequest failed" {
+		t.Fatalf("exit error got code %d stderr %q", exitErr.ExitCode, exitErr.Stderr)
+	}
+
+	if err = clientT.Send(context.Background(), Message("request")); !errors.As(err, &exitErr) {
+		t.Fatalf("Send after exit got %v, want *pkg.ProcessExitError", err)
+	}
+	_ = clientT.Close()
+}
+
 func compileMockStdioServerTr(outputPath string) error {
 	cmd := exec.Command("go", "build", "-o", outputPath, "../testdata/mock_block_server.go")
 
@@ -89,3 +143,107 @@ func compileMockStdioServerTr(outputPath string) error {
 
 	return nil
 }
+
+func TestStdioClientProcessExitWithChild(t *testing.T) {
+	// the background child keeps the pipes open after the exit of the shell
+	clientT, err := NewStdioClientTransport("sh", []string{"-c", "sleep 10 & echo exiting >&2; exit 2"})
+	if err != nil {
+		t.Fatalf("NewStdioClientTransport failed: %v", err)
+	}
+	clientT.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+
+	states := make(chan ConnectionState, 1)
+	clientT.(ConnectionStateNotifier).AddConnectionStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+	if err = clientT.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer clientT.Close()
+
+	select {
+	case state := <-states:
+		if state != ConnectionStateDisconnected {
+			t.Fatalf("connection state got %s, want %s", state, ConnectionStateDisconnected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process exit not detected")
+	}
+
+	var exitErr *pkg.ProcessExitError
+	if err = clientT.(ConnectionErrorReporter).ConnectionError(); !errors.As(err, &exitErr) {
+		t.Fatalf("connection error got %v, want *pkg.ProcessExitError", err)
+	}
+	if exitErr.ExitCode != 2 || exitErr.Stderr != "exiting" {
+		t.Fatalf("exit error got code %d stderr %q", exitErr.ExitCode, exitErr.Stderr)
+	}
+}
+
+func TestStdioClientCloseNotStarted(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		start   bool
+	}{
+		{name: "test_never_started", command: "sh"},
+		{name: "test_start_failed", command: filepath.Join(t.TempDir(), "missing"), start: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientT, err := NewStdioClientTransport(tt.command, nil)
+			if err != nil {
+				t.Fatalf("NewStdioClientTransport failed: %v", err)
+			}
+			if tt.start {
+				if err = clientT.Start(); err == nil {
+					t.Fatalf("Start succeeded, want error")
+				}
+			}
+
+			closed := make(chan error, 1)
+			go func() {
+				closed <- clientT.Close()
+			}()
+			select {
+			case err = <-closed:
+				if err != nil {
+					t.Fatalf("Close failed: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Close blocked")
+			}
+		})
+	}
+}
+
+func TestStdioClientReceive(t *testing.T) {
+	// a message larger than the default buffer of bufio.Scanner, then one following a receive error
+	clientT, err := NewStdioClientTransport("sh", []string{"-c", `head -c 100000 /dev/zero | tr '\0' x; echo; echo second; echo third; read line`})
+	if err != nil {
+		t.Fatalf("NewStdioClientTransport failed: %v", err)
+	}
+	received := make(chan string, 3)
+	clientT.SetReceiver(ClientReceiverF(func(_ context.Context, msg []byte) error {
+		received <- string(msg)
+		if string(msg) == "second" {
+			return errors.New("receive failed")
+		}
+		return nil
+	}))
+	if err = clientT.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer clientT.Close()
+
+	for _, wantLen := range []int{100000, len("second"), len("third")} {
+		select {
+		case msg := <-received:
+			if len(msg) != wantLen {
+				t.Fatalf("message length got %d, want %d", len(msg), wantLen)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message of length %d not received", wantLen)
+		}
+	}
+}
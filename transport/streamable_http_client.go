@@ -0,0 +1,365 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+type StreamableHTTPClientTransportOption func(*streamableHTTPClientTransport)
+
+func WithStreamableHTTPClientOptionReceiveTimeout(timeout time.Duration) StreamableHTTPClientTransportOption {
+	return func(t *streamableHTTPClientTransport) {
+		t.receiveTimeout = timeout
+	}
+}
+
+func WithStreamableHTTPClientOptionHTTPClient(client *http.Client) StreamableHTTPClientTransportOption {
+	return func(t *streamableHTTPClientTransport) {
+		t.client = client
+	}
+}
+
+func WithStreamableHTTPClientOptionLogger(log pkg.Logger) StreamableHTTPClientTransportOption {
+	return func(t *streamableHTTPClientTransport) {
+		t.logger = log
+	}
+}
+
+// WithStreamableHTTPClientOptionGetStream sets whether a GET stream is opened to receive the messages
+// the server sends outside of any request, it is enabled by default.
+func WithStreamableHTTPClientOptionGetStream(enable bool) StreamableHTTPClientTransportOption {
+	return func(t *streamableHTTPClientTransport) {
+		t.getStream = enable
+	}
+}
+
+type streamableHTTPClientTransport struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	serverURL *url.URL
+
+	receiver clientReceiver
+
+	// sessionID is issued by the server in the response to the initialize request
+	mu        sync.RWMutex
+	sessionID string
+	// protocolVersion is negotiated by the initialization, and sent in the MCP-Protocol-Version header of the following requests
+	protocolVersion string
+
+	getStreamOnce sync.Once
+
+	// streams being read, waited for on Close
+	inFlyStream sync.WaitGroup
+
+	// options
+	logger         pkg.Logger
+	receiveTimeout time.Duration
+	client         *http.Client
+	getStream      bool
+}
+
+func NewStreamableHTTPClientTransport(serverURL string, opts ...StreamableHTTPClientTransportOption) (ClientTransport, error) {
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server URL: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &streamableHTTPClientTransport{
+		ctx:            ctx,
+		cancel:         cancel,
+		serverURL:      parsedURL,
+		logger:         pkg.DefaultLogger,
+		receiveTimeout: time.Second * 30,
+		client:         http.DefaultClient,
+		getStream:      true,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t, nil
+}
+
+// Start does nothing, the session is established by the initialize request sent through Send
+func (t *streamableHTTPClientTransport) Start() error {
+	return nil
+}
+
+// Send POSTs msg to the server, the messages answered with application/json or a text/event-stream
+// are passed to the receiver, the stream being read in the background until the server ends it.
+// ctx only bounds the wait for the response headers, the stream outliving Send is bound to the transport.
+func (t *streamableHTTPClientTransport) Send(ctx context.Context, msg Message) error {
+	t.logger.Debugf("Sending message: %s to %s", msg, t.serverURL.String())
+
+	reqCtx, cancelReq := context.WithCancel(t.ctx)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, t.serverURL.String(), bytes.NewReader(msg))
+	if err != nil {
+		cancelReq()
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	sessionID := t.getSessionID()
+	t.setSessionHeaders(req.Header, sessionID)
+
+	resp, err := t.doUntilHeaders(ctx, req, cancelReq) //nolint:bodyclose
+	if err != nil {
+		cancelReq()
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		_ = resp.Body.Close()
+		cancelReq()
+		if resp.StatusCode == http.StatusNotFound && t.getSessionID() != "" {
+			return fmt.Errorf("%w: session expired, status: %s", pkg.ErrLackSession, resp.Status)
+		}
+		return fmt.Errorf("unexpected status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+
+	if newSessionID := resp.Header.Get(sessionIDHeader); newSessionID != "" {
+		t.setSessionID(newSessionID)
+	}
+
+	// the GET stream is opened with the first message following the initialization, so that it carries the negotiated version
+	if sessionID != "" && t.getStream {
+		t.getStreamOnce.Do(t.startGetStream)
+	}
+
+	switch contentType := resp.Header.Get("Content-Type"); {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		t.inFlyStream.Add(1)
+		go func() {
+			defer pkg.Recover()
+			defer t.inFlyStream.Done()
+			defer cancelReq()
+
+			t.readSSE(resp.Body)
+		}()
+	case strings.HasPrefix(contentType, "application/json"):
+		defer cancelReq()
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		t.receive(body)
+	default:
+		_ = resp.Body.Close()
+		cancelReq()
+	}
+	return nil
+}
+
+// doUntilHeaders sends req, whose ctx is cancelled by cancelReq, giving up with ctx until the response headers arrive
+func (t *streamableHTTPClientTransport) doUntilHeaders(ctx context.Context, req *http.Request, cancelReq context.CancelFunc) (*http.Response, error) {
+	headersReceived := make(chan struct{})
+	watchDone := make(chan struct{})
+	var cancelled bool
+	go func() {
+		defer close(watchDone)
+
+		select {
+		case <-ctx.Done():
+			cancelled = true
+			cancelReq()
+		case <-headersReceived:
+		}
+	}()
+
+	resp, err := t.client.Do(req)
+	close(headersReceived)
+	<-watchDone
+
+	if cancelled {
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return nil, ctx.Err()
+	}
+	return resp, err
+}
+
+// startGetStream opens the stream on which the server sends the messages unrelated to any request,
+// a server that does not offer it answers 405.
+func (t *streamableHTTPClientTransport) startGetStream() {
+	t.inFlyStream.Add(1)
+	go func() {
+		defer pkg.Recover()
+		defer t.inFlyStream.Done()
+
+		req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.serverURL.String(), nil)
+		if err != nil {
+			t.logger.Errorf("failed to create get stream request: %v", err)
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		t.setSessionHeaders(req.Header, t.getSessionID())
+
+		resp, err := t.client.Do(req) //nolint:bodyclose
+		if err != nil {
+			t.logger.Errorf("failed to open get stream: %v", err)
+			return
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			t.logger.Debugf("server does not offer get stream, status: %s", resp.Status)
+			return
+		}
+
+		t.readSSE(resp.Body)
+	}()
+}
+
+// readSSE reads the message events of the stream until it ends or the transport is closed.
+func (t *streamableHTTPClientTransport) readSSE(reader io.ReadCloser) {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer pkg.Recover()
+
+		select {
+		case <-t.ctx.Done():
+			_ = reader.Close()
+		case <-done:
+		}
+	}()
+	defer reader.Close()
+
+	br := bufio.NewReader(reader)
+	var event string
+	var data []string
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if err != io.EOF && !errors.Is(err, context.Canceled) && t.ctx.Err() == nil {
+				t.logger.Errorf("streamable http stream error: %v", err)
+			}
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			// empty line means end of event
+			if len(data) > 0 && (event == "" || event == "message") {
+				t.receive([]byte(strings.Join(data, "\n")))
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+}
+
+func (t *streamableHTTPClientTransport) receive(msg []byte) {
+	ctx, cancel := context.WithTimeout(t.ctx, t.receiveTimeout)
+	defer cancel()
+
+	if err := t.receiver.Receive(ctx, msg); err != nil {
+		t.logger.Errorf("Error receive message: %v", err)
+	}
+}
+
+func (t *streamableHTTPClientTransport) getSessionID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.sessionID
+}
+
+func (t *streamableHTTPClientTransport) setSessionID(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sessionID = sessionID
+}
+
+// SetProtocolVersion implements ProtocolVersionSetter
+func (t *streamableHTTPClientTransport) SetProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.protocolVersion = version
+}
+
+func (t *streamableHTTPClientTransport) getProtocolVersion() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.protocolVersion
+}
+
+// setSessionHeaders sets the headers identifying the session and its protocol version, once they are known
+func (t *streamableHTTPClientTransport) setSessionHeaders(header http.Header, sessionID string) {
+	if sessionID != "" {
+		header.Set(sessionIDHeader, sessionID)
+	}
+	if version := t.getProtocolVersion(); version != "" {
+		header.Set(protocolVersionHeader, version)
+	}
+}
+
+func (t *streamableHTTPClientTransport) SetReceiver(receiver clientReceiver) {
+	t.receiver = receiver
+}
+
+// Close terminates the session with a DELETE request, then closes the open streams.
+func (t *streamableHTTPClientTransport) Close() error {
+	if sessionID := t.getSessionID(); sessionID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := t.terminateSession(ctx, sessionID); err != nil {
+			t.logger.Warnf("failed to terminate session: %v", err)
+		}
+	}
+
+	t.cancel()
+
+	t.inFlyStream.Wait()
+
+	return nil
+}
+
+func (t *streamableHTTPClientTransport) terminateSession(ctx context.Context, sessionID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.serverURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	t.setSessionHeaders(req.Header, sessionID)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 405 means the server does not allow clients to terminate sessions
+	if resp.StatusCode != http.StatusMethodNotAllowed && (resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices) {
+		return fmt.Errorf("unexpected status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
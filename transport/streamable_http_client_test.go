@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestStreamableHTTPClient(t *testing.T) {
+	svr, httpSvr := newTestStreamableHTTPServer(t)
+
+	received := make(chan string, 3)
+	client, err := NewStreamableHTTPClientTransport(httpSvr.URL)
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPClientTransport failed: %v", err)
+	}
+	client.SetReceiver(ClientReceiverF(func(_ context.Context, msg []byte) error {
+		received <- string(msg)
+		return nil
+	}))
+	if err = client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+
+	if err = client.Send(context.Background(), Message(testInitializeRequest)); err != nil {
+		t.Fatalf("client.Send() failed: %v", err)
+	}
+
+	// the server answers the request with a notification followed by the response on the POST stream
+	for _, want := range []string{`{"jsonrpc":"2.0","method":"notifications/message"}`, `{"jsonrpc":"2.0","id":1,"result":{}}`} {
+		if got := <-received; got != want {
+			t.Fatalf("received got %s, want %s", got, want)
+		}
+	}
+
+	sessionID := client.(*streamableHTTPClientTransport).getSessionID()
+	if sessionID == "" {
+		t.Fatalf("session id not kept")
+	}
+
+	// the GET stream is opened with the first message following the initialization
+	client.(ProtocolVersionSetter).SetProtocolVersion("2025-03-26")
+	if err = client.Send(context.Background(), Message(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); err != nil {
+		t.Fatalf("client.Send() failed: %v", err)
+	}
+
+	// wait for the GET stream, then send on it
+	msg := `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, toGetStream := svr.(*streamableHTTPServerTransport).pickStream(sessionID, "", false); toGetStream {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("get stream not opened")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err = svr.Send(context.Background(), sessionID, Message(msg)); err != nil {
+		t.Fatalf("server.Send() failed: %v", err)
+	}
+	if got := <-received; got != msg {
+		t.Fatalf("received got %s, want %s", got, msg)
+	}
+
+	if err = client.Close(); err != nil {
+		t.Fatalf("client.Close() failed: %v", err)
+	}
+	if svr.(*streamableHTTPServerTransport).sessionManager.(*mockSessionManager).IsExistSession(sessionID) {
+		t.Fatalf("session not terminated on close")
+	}
+}
+
+func TestStreamableHTTPClientProtocolVersionHeader(t *testing.T) {
+	_, httpSvr := newTestStreamableHTTPServer(t)
+
+	target, err := url.Parse(httpSvr.URL)
+	if err != nil {
+		t.Fatalf("url.Parse failed: %v", err)
+	}
+	reverseProxy := httputil.NewSingleHostReverseProxy(target)
+	versions := make(chan string, 2)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			versions <- r.Header.Get(protocolVersionHeader)
+		}
+		reverseProxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	client, err := NewStreamableHTTPClientTransport(proxy.URL, WithStreamableHTTPClientOptionGetStream(false))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPClientTransport failed: %v", err)
+	}
+	client.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+	if err = client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if err = client.Send(context.Background(), Message(testInitializeRequest)); err != nil {
+		t.Fatalf("client.Send() failed: %v", err)
+	}
+	client.(ProtocolVersionSetter).SetProtocolVersion("2025-03-26")
+	if err = client.Send(context.Background(), Message(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); err != nil {
+		t.Fatalf("client.Send() failed: %v", err)
+	}
+
+	// the initialize request is sent before the version is negotiated
+	for _, want := range []string{"", "2025-03-26"} {
+		if got := <-versions; got != want {
+			t.Fatalf("protocol version header got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestStreamableHTTPClientStreamOutlivesSendCtx(t *testing.T) {
+	release := make(chan struct{})
+	httpSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		<-release
+		_, _ = w.Write([]byte("event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n\n"))
+		w.(http.Flusher).Flush()
+	}))
+	defer httpSvr.Close()
+
+	client, err := NewStreamableHTTPClientTransport(httpSvr.URL, WithStreamableHTTPClientOptionGetStream(false))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPClientTransport failed: %v", err)
+	}
+	received := make(chan string, 1)
+	client.SetReceiver(ClientReceiverF(func(_ context.Context, msg []byte) error {
+		received <- string(msg)
+		return nil
+	}))
+	if err = client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer client.Close()
+
+	// the ctx of the call is done once Send returned, before the stream ends
+	ctx, cancel := context.WithCancel(context.Background())
+	if err = client.Send(ctx, Message(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)); err != nil {
+		t.Fatalf("client.Send() failed: %v", err)
+	}
+	cancel()
+	close(release)
+
+	select {
+	case msg := <-received:
+		if msg != `{"jsonrpc":"2.0","id":1,"result":{}}` {
+			t.Fatalf("message got %s", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message of the stream not received")
+	}
+}
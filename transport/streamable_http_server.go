@@ -0,0 +1,707 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+const (
+	sessionIDHeader = "Mcp-Session-Id"
+	// protocolVersionHeader carries the negotiated protocol version in the requests following the initialization
+	protocolVersionHeader = "MCP-Protocol-Version"
+)
+
+type StreamableHTTPServerTransportOption func(*streamableHTTPServerTransport)
+
+func WithStreamableHTTPServerTransportOptionLogger(logger pkg.Logger) StreamableHTTPServerTransportOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.logger = logger
+	}
+}
+
+func WithStreamableHTTPServerTransportOptionEndpoint(endpoint string) StreamableHTTPServerTransportOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.endpoint = endpoint
+	}
+}
+
+// WithStreamableHTTPServerTransportOptionAllowedOrigins answers 403 to the requests whose Origin is not one of origins,
+// "*" allowing any. Every origin is allowed by default.
+func WithStreamableHTTPServerTransportOptionAllowedOrigins(origins ...string) StreamableHTTPServerTransportOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.allowedOrigins = origins
+	}
+}
+
+// WithStreamableHTTPServerTransportOptionJSONResponse answers POST requests with a single application/json response
+// instead of an SSE stream, server-initiated messages are then only sent on the GET stream.
+func WithStreamableHTTPServerTransportOptionJSONResponse(enable bool) StreamableHTTPServerTransportOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.jsonResponse = enable
+	}
+}
+
+type StreamableHTTPServerTransportAndHandlerOption func(*streamableHTTPServerTransport)
+
+func WithStreamableHTTPServerTransportAndHandlerOptionLogger(logger pkg.Logger) StreamableHTTPServerTransportAndHandlerOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.logger = logger
+	}
+}
+
+func WithStreamableHTTPServerTransportAndHandlerOptionJSONResponse(enable bool) StreamableHTTPServerTransportAndHandlerOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.jsonResponse = enable
+	}
+}
+
+func WithStreamableHTTPServerTransportAndHandlerOptionAllowedOrigins(origins ...string) StreamableHTTPServerTransportAndHandlerOption {
+	return func(t *streamableHTTPServerTransport) {
+		t.allowedOrigins = origins
+	}
+}
+
+type streamableHTTPServerTransport struct {
+	// ctx is the context that controls the lifecycle of the server.
+	// It is used to coordinate cancellation of all ongoing send operations when the server is shutting down.
+	ctx context.Context
+	// cancel is the function to cancel the ctx when the server needs to shut down.
+	cancel context.CancelFunc
+
+	httpSvr *http.Server
+
+	inFlySend sync.WaitGroup
+
+	receiver serverReceiver
+
+	sessionManager sessionManager
+
+	// protocol versions accepted in the MCP-Protocol-Version header, those of the server using the transport
+	supportedVersions []string
+
+	// open streams of the sessions, a session has an entry only while it has at least one open stream
+	mu      sync.Mutex
+	streams map[string]*sessionStreams
+
+	// options
+	logger         pkg.Logger
+	endpoint       string
+	jsonResponse   bool
+	allowedOrigins []string
+}
+
+// sessionStreams are the HTTP responses a session's messages can currently be written to
+type sessionStreams struct {
+	// the GET stream consumes the messages queued in the session manager, getStream is closed to end it, nil while not open
+	getStream chan struct{}
+	// the POST streams waiting for the response of a request, keyed by the key of the request id
+	postStreams map[string]*postStream
+}
+
+type postStream struct {
+	// sse is false when the POST is answered with application/json, in which case it only accepts the response
+	sse bool
+
+	msgCh chan []byte
+	done  chan struct{}
+
+	// aborted is closed when no response is coming anymore, the requests being cancelled or the session deleted
+	aborted   chan struct{}
+	abortOnce sync.Once
+	// pending is the number of requests of the POST not cancelled
+	pending int
+}
+
+func (s *postStream) abort() {
+	s.abortOnce.Do(func() {
+		close(s.aborted)
+	})
+}
+
+func (s *postStream) send(ctx context.Context, msg []byte) error {
+	select {
+	case s.msgCh <- msg:
+		return nil
+	case <-s.done:
+		return errors.New("stream already closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type StreamableHTTPHandler struct {
+	transport *streamableHTTPServerTransport
+}
+
+// HandleMCP handles the POST, GET and DELETE requests sent by clients to the MCP endpoint.
+func (h *StreamableHTTPHandler) HandleMCP() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.transport.handleMCP(w, r)
+	})
+}
+
+// NewStreamableHTTPServerTransport returns transport that will start an HTTP server
+func NewStreamableHTTPServerTransport(addr string, opts ...StreamableHTTPServerTransportOption) (ServerTransport, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &streamableHTTPServerTransport{
+		ctx:               ctx,
+		cancel:            cancel,
+		supportedVersions: protocol.SupportedVersions,
+		streams:           make(map[string]*sessionStreams),
+		logger:            pkg.DefaultLogger,
+		endpoint:          "/mcp",
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(t.endpoint, t.handleMCP)
+
+	t.httpSvr = &http.Server{
+		Addr:        addr,
+		Handler:     mux,
+		IdleTimeout: time.Minute,
+	}
+
+	return t, nil
+}
+
+// NewStreamableHTTPServerTransportAndHandler returns transport without starting the HTTP server,
+// and returns a Handler for users to start their own HTTP server externally
+// eg:
+// transport, handler, _ := NewStreamableHTTPServerTransportAndHandler()
+// http.Handle("/mcp", handler.HandleMCP())
+// http.ListenAndServe(":8080", nil)
+func NewStreamableHTTPServerTransportAndHandler(
+	opts ...StreamableHTTPServerTransportAndHandlerOption,
+) (ServerTransport, *StreamableHTTPHandler, error) { //nolint:whitespace
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &streamableHTTPServerTransport{
+		ctx:               ctx,
+		cancel:            cancel,
+		supportedVersions: protocol.SupportedVersions,
+		streams:           make(map[string]*sessionStreams),
+		logger:            pkg.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t, &StreamableHTTPHandler{transport: t}, nil
+}
+
+func (t *streamableHTTPServerTransport) Run() error {
+	if t.httpSvr == nil {
+		<-t.ctx.Done()
+		return nil
+	}
+
+	if err := t.httpSvr.ListenAndServe(); err != nil {
+		return fmt.Errorf("failed to start HTTP server: %w", err)
+	}
+	return nil
+}
+
+// Send writes the response of a request to the POST stream waiting for it,
+// other messages go to the GET stream of the session, or to one of its POST streams when no GET stream is open.
+func (t *streamableHTTPServerTransport) Send(ctx context.Context, sessionID string, msg Message) error {
+	t.inFlySend.Add(1)
+	defer t.inFlySend.Done()
+
+	select {
+	case <-t.ctx.Done():
+		return errors.New("streamable http server transport already shutdown")
+	default:
+	}
+
+	if !t.sessionManager.IsExistSession(sessionID) {
+		return pkg.ErrLackSession
+	}
+
+	// the responses to a batch are sent together, on the stream of the batch, found by the id of one of them
+	first := gjson.ParseBytes(msg)
+	if first.IsArray() {
+		for _, member := range first.Array() {
+			if member.Get("id").Type != gjson.Null {
+				first = member
+				break
+			}
+		}
+	}
+	isResponse := !first.Get("method").Exists()
+	requestID := requestIDKeyOf(first.Get("id"))
+	if !isResponse {
+		requestID, _ = getRelatedRequestID(ctx)
+	}
+	stream, toGetStream := t.pickStream(sessionID, requestID, isResponse)
+	if toGetStream {
+		return t.sessionManager.SendMessage(ctx, sessionID, msg)
+	}
+	if stream == nil {
+		return fmt.Errorf("%w: sessionID=%s", pkg.ErrLackStream, sessionID)
+	}
+	return stream.send(ctx, msg)
+}
+
+// pickStream returns the POST stream of the request with requestID, the one a response answers,
+// or the one a request or notification is related to, which otherwise goes to the GET stream, reported by true.
+func (t *streamableHTTPServerTransport) pickStream(sessionID string, requestID string, isResponse bool) (*postStream, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	streams, ok := t.streams[sessionID]
+	if !ok {
+		return nil, false
+	}
+
+	if isResponse {
+		return streams.postStreams[requestID], false
+	}
+
+	if stream, ok := streams.postStreams[requestID]; ok && stream.sse {
+		return stream, false
+	}
+	return nil, streams.getStream != nil
+}
+
+func (t *streamableHTTPServerTransport) SetReceiver(receiver serverReceiver) {
+	t.receiver = receiver
+}
+
+func (t *streamableHTTPServerTransport) SetSessionManager(manager sessionManager) {
+	t.sessionManager = manager
+	if notifier, ok := manager.(sessionCloseNotifier); ok {
+		notifier.AddSessionCloseHandler(t.closeStreams)
+	}
+}
+
+func (t *streamableHTTPServerTransport) SetSupportedVersions(versions []string) {
+	t.supportedVersions = versions
+}
+
+func (t *streamableHTTPServerTransport) handleMCP(w http.ResponseWriter, r *http.Request) {
+	defer pkg.RecoverWithFunc(func(_ any) {
+		t.writeError(w, http.StatusInternalServerError, "Internal server error")
+	})
+
+	if !isOriginAllowed(r, t.allowedOrigins) {
+		t.writeError(w, http.StatusForbidden, "Origin not allowed")
+		return
+	}
+
+	// a request without the header is assumed to use 2025-03-26, which did not define it
+	if version := r.Header.Get(protocolVersionHeader); version != "" && !protocol.IsVersionSupported(version, t.supportedVersions) {
+		t.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported protocol version: %s", version))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleGet(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		t.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handlePost receives a message from the client, the response of a request is written back on the same HTTP response.
+func (t *streamableHTTPServerTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	bs, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	newSession := false
+	sessionID := r.Header.Get(sessionIDHeader)
+	if sessionID == "" {
+		if gjson.GetBytes(bs, "method").String() != "initialize" {
+			t.writeError(w, http.StatusBadRequest, "Missing session ID")
+			return
+		}
+		sessionID = uuid.New().String()
+		t.sessionManager.CreateSession(sessionID)
+		newSession = true
+	} else if !t.sessionManager.IsExistSession(sessionID) {
+		t.writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	w.Header().Set(sessionIDHeader, sessionID)
+
+	t.logger.Debugf("Received message: %s", string(bs))
+
+	// the id keys of the request, or of the requests of a batch
+	requestIDs := requestIDsOf(bs)
+	if len(requestIDs) == 0 {
+		if err = t.receiver.Receive(r.Context(), sessionID, bs); err != nil {
+			t.writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to receive: %v", err))
+			return
+		}
+		// a cancelled request is not answered, so its stream is ended without waiting for the response
+		for _, requestID := range cancelledRequestIDsOf(bs) {
+			t.cancelPostStream(sessionID, requestID)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	sse := !t.jsonResponse && strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+
+	// registered before the request is received, so that a fast response is not missed
+	stream := t.openPostStream(sessionID, requestIDs, sse)
+	defer t.closePostStream(sessionID, requestIDs, stream)
+
+	if err = t.receiver.Receive(r.Context(), sessionID, bs); err != nil {
+		if newSession {
+			t.sessionManager.CloseSession(sessionID)
+		}
+		t.writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to receive: %v", err))
+		return
+	}
+
+	if !sse {
+		select {
+		case msg := <-stream.msgCh:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			if _, err = w.Write(msg); err != nil {
+				t.logger.Errorf("Failed to write message: %v", err)
+			}
+		case <-stream.aborted:
+			w.WriteHeader(http.StatusAccepted)
+		case <-r.Context().Done():
+		case <-t.ctx.Done():
+		}
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-stream.msgCh:
+			t.logger.Debugf("Sending message: %s", string(msg))
+
+			if _, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+				t.logger.Errorf("Failed to write message: %v", err)
+				return
+			}
+			flusher.Flush()
+
+			// the stream ends with the response of the request
+			if !gjson.GetBytes(msg, "method").Exists() {
+				return
+			}
+		case <-stream.aborted:
+			return
+		case <-r.Context().Done():
+			return
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleGet opens the stream on which the server sends the messages unrelated to any request of the client.
+func (t *streamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		t.writeError(w, http.StatusNotAcceptable, "Client must accept text/event-stream")
+		return
+	}
+
+	sessionID := r.Header.Get(sessionIDHeader)
+	if sessionID == "" {
+		t.writeError(w, http.StatusBadRequest, "Missing session ID")
+		return
+	}
+	if !t.sessionManager.IsExistSession(sessionID) {
+		t.writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.writeError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	getStream := t.openGetStream(sessionID)
+	if getStream == nil {
+		t.writeError(w, http.StatusConflict, "Stream already open")
+		return
+	}
+	defer t.closeGetStream(sessionID, getStream)
+
+	// the stream ends when the session is deleted
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-getStream:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(sessionIDHeader, sessionID)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		msg, err := t.sessionManager.GetMessageForSend(ctx, sessionID)
+		if err != nil {
+			if !errors.Is(err, pkg.ErrSendEOF) {
+				t.logger.Debugf("streamable http get request err: %+v, sessionID=%s", err.Error(), sessionID)
+			}
+			return
+		}
+
+		t.logger.Debugf("Sending message: %s", string(msg))
+
+		if _, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+			t.logger.Errorf("Failed to write message: %v", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleDelete terminates the session at the request of the client.
+func (t *streamableHTTPServerTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(sessionIDHeader)
+	if sessionID == "" {
+		t.writeError(w, http.StatusBadRequest, "Missing session ID")
+		return
+	}
+	if !t.sessionManager.IsExistSession(sessionID) {
+		t.writeError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	t.sessionManager.CloseSession(sessionID)
+	// ended here as well for the session managers that don't report the sessions they close
+	t.closeStreams(sessionID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// openPostStream opens the stream of a POST, on which the responses of its requests are sent
+func (t *streamableHTTPServerTransport) openPostStream(sessionID string, requestIDs []string, sse bool) *postStream {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stream := &postStream{
+		sse:     sse,
+		msgCh:   make(chan []byte),
+		done:    make(chan struct{}),
+		aborted: make(chan struct{}),
+		pending: len(requestIDs),
+	}
+	streams := t.getOrCreateStreams(sessionID)
+	for _, requestID := range requestIDs {
+		streams.postStreams[requestID] = stream
+	}
+	return stream
+}
+
+func (t *streamableHTTPServerTransport) closePostStream(sessionID string, requestIDs []string, stream *postStream) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	close(stream.done)
+
+	streams, ok := t.streams[sessionID]
+	if !ok {
+		return
+	}
+	for _, requestID := range requestIDs {
+		if streams.postStreams[requestID] == stream {
+			delete(streams.postStreams, requestID)
+		}
+	}
+	t.removeStreamsIfEmpty(sessionID, streams)
+}
+
+// cancelPostStream aborts the POST stream of the cancelled request, once none of its requests is pending
+func (t *streamableHTTPServerTransport) cancelPostStream(sessionID string, requestID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	streams, ok := t.streams[sessionID]
+	if !ok {
+		return
+	}
+	stream, ok := streams.postStreams[requestID]
+	if !ok {
+		return
+	}
+	delete(streams.postStreams, requestID)
+	if stream.pending--; stream.pending == 0 {
+		stream.abort()
+	}
+}
+
+// closeStreams ends the open streams of the closed session
+func (t *streamableHTTPServerTransport) closeStreams(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	streams, ok := t.streams[sessionID]
+	if !ok {
+		return
+	}
+	if streams.getStream != nil {
+		close(streams.getStream)
+		streams.getStream = nil
+	}
+	for _, stream := range streams.postStreams {
+		stream.abort()
+	}
+}
+
+// cancelledRequestIDsOf returns the id keys of the requests cancelled by the notifications/cancelled in msg
+func cancelledRequestIDsOf(msg []byte) []string {
+	members := []gjson.Result{gjson.ParseBytes(msg)}
+	if members[0].IsArray() {
+		members = members[0].Array()
+	}
+
+	var requestIDs []string
+	for _, member := range members {
+		if member.Get("method").String() == string(protocol.NotificationCancelled) {
+			if requestID := member.Get("params.requestId"); requestID.Exists() {
+				requestIDs = append(requestIDs, requestIDKeyOf(requestID))
+			}
+		}
+	}
+	return requestIDs
+}
+
+// requestIDsOf returns the id keys of the requests in msg, a single message or a batch
+func requestIDsOf(msg []byte) []string {
+	members := []gjson.Result{gjson.ParseBytes(msg)}
+	if members[0].IsArray() {
+		members = members[0].Array()
+	}
+
+	var requestIDs []string
+	for _, member := range members {
+		if member.Get("method").Exists() && member.Get("id").Exists() {
+			requestIDs = append(requestIDs, requestIDKeyOf(member.Get("id")))
+		}
+	}
+	return requestIDs
+}
+
+// requestIDKeyOf returns the key of the JSON-RPC id, the same as protocol.RequestIDKey of the decoded id
+func requestIDKeyOf(id gjson.Result) string {
+	if id.Type == gjson.String {
+		return protocol.RequestIDKey(id.String())
+	}
+	return protocol.RequestIDKey(json.Number(id.Raw))
+}
+
+// openGetStream returns the channel closed to end the GET stream, nil when the session already has one
+func (t *streamableHTTPServerTransport) openGetStream(sessionID string) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	streams := t.getOrCreateStreams(sessionID)
+	if streams.getStream != nil {
+		return nil
+	}
+	streams.getStream = make(chan struct{})
+	return streams.getStream
+}
+
+func (t *streamableHTTPServerTransport) closeGetStream(sessionID string, getStream chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	streams, ok := t.streams[sessionID]
+	if !ok {
+		return
+	}
+	if streams.getStream == getStream {
+		streams.getStream = nil
+	}
+	t.removeStreamsIfEmpty(sessionID, streams)
+}
+
+func (t *streamableHTTPServerTransport) getOrCreateStreams(sessionID string) *sessionStreams {
+	streams, ok := t.streams[sessionID]
+	if !ok {
+		streams = &sessionStreams{postStreams: make(map[string]*postStream)}
+		t.streams[sessionID] = streams
+	}
+	return streams
+}
+
+func (t *streamableHTTPServerTransport) removeStreamsIfEmpty(sessionID string, streams *sessionStreams) {
+	if streams.getStream == nil && len(streams.postStreams) == 0 {
+		delete(t.streams, sessionID)
+	}
+}
+
+// writeError writes an HTTP error response with the given error details.
+func (t *streamableHTTPServerTransport) writeError(w http.ResponseWriter, code int, message string) {
+	t.logger.Errorf("streamableHTTPServerTransport writeError: code: %d, message: %s", code, message)
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(code)
+	if _, err := w.Write([]byte(message)); err != nil {
+		t.logger.Errorf("streamableHTTPServerTransport writeError: %+v", err)
+	}
+}
+
+func (t *streamableHTTPServerTransport) Shutdown(userCtx context.Context, serverCtx context.Context) error {
+	shutdownFunc := func() {
+		<-serverCtx.Done()
+
+		t.cancel()
+
+		t.inFlySend.Wait()
+
+		t.sessionManager.CloseAllSessions()
+	}
+
+	if t.httpSvr == nil {
+		shutdownFunc()
+		return nil
+	}
+
+	t.httpSvr.RegisterOnShutdown(shutdownFunc)
+
+	if err := t.httpSvr.Shutdown(userCtx); err != nil {
+		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,581 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// closeNotifyingSessionManager reports the sessions it closes, as the session manager of the server does
+type closeNotifyingSessionManager struct {
+	*mockSessionManager
+	closeHandlers []func(sessionID string)
+}
+
+func (m *closeNotifyingSessionManager) AddSessionCloseHandler(handler func(sessionID string)) {
+	m.closeHandlers = append(m.closeHandlers, handler)
+}
+
+func (m *closeNotifyingSessionManager) CloseSession(sessionID string) {
+	m.mockSessionManager.CloseSession(sessionID)
+	for _, handler := range m.closeHandlers {
+		handler(sessionID)
+	}
+}
+
+func newTestStreamableHTTPServer(t *testing.T, opts ...StreamableHTTPServerTransportAndHandlerOption) (ServerTransport, *httptest.Server) {
+	svr, handler, err := NewStreamableHTTPServerTransportAndHandler(opts...)
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPServerTransportAndHandler failed: %v", err)
+	}
+	svr.SetSessionManager(newMockSessionManager())
+
+	// answer every request with a notification followed by the response
+	svr.SetReceiver(ServerReceiverF(func(_ context.Context, sessionID string, msg []byte) error {
+		id := gjson.GetBytes(msg, "id")
+		if !id.Exists() || !gjson.GetBytes(msg, "method").Exists() {
+			return nil
+		}
+		go func() {
+			ctx := WithRelatedRequestID(context.Background(), id.Value())
+			if err := svr.Send(ctx, sessionID, Message(`{"jsonrpc":"2.0","method":"notifications/message"}`)); err != nil {
+				t.Errorf("send notification: %v", err)
+			}
+			if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":`+id.Raw+`,"result":{}}`)); err != nil {
+				t.Errorf("send response: %v", err)
+			}
+		}()
+		return nil
+	}))
+
+	httpSvr := httptest.NewServer(handler.HandleMCP())
+	t.Cleanup(httpSvr.Close)
+	return svr, httpSvr
+}
+
+func doStreamableHTTPRequest(t *testing.T, method, url, sessionID, accept, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	if sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do request failed: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+const testInitializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+
+func TestStreamableHTTPServerPost(t *testing.T) {
+	tests := []struct {
+		name          string
+		jsonResponse  bool
+		expectedType  string
+		expectedDatas []string
+	}{
+		{
+			name:         "test_sse_response",
+			expectedType: "text/event-stream",
+			expectedDatas: []string{
+				`{"jsonrpc":"2.0","method":"notifications/message"}`,
+				`{"jsonrpc":"2.0","id":1,"result":{}}`,
+			},
+		},
+		{
+			name:          "test_json_response",
+			jsonResponse:  true,
+			expectedType:  "application/json",
+			expectedDatas: []string{`{"jsonrpc":"2.0","id":1,"result":{}}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svr, httpSvr := newTestStreamableHTTPServer(t, WithStreamableHTTPServerTransportAndHandlerOptionJSONResponse(tt.jsonResponse))
+
+			// with JSON responses the notification has no stream to go to
+			if tt.jsonResponse {
+				svr.SetReceiver(ServerReceiverF(func(_ context.Context, sessionID string, _ []byte) error {
+					go func() {
+						_ = svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+					}()
+					return nil
+				}))
+			}
+
+			resp := doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "application/json, text/event-stream", testInitializeRequest)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status code got %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if resp.Header.Get(sessionIDHeader) == "" {
+				t.Fatalf("session id header missing")
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.expectedType {
+				t.Fatalf("content type got %s, want %s", got, tt.expectedType)
+			}
+
+			var datas []string
+			if tt.jsonResponse {
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatalf("read body failed: %v", err)
+				}
+				datas = append(datas, string(body))
+			} else {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+						datas = append(datas, data)
+					}
+				}
+			}
+
+			if strings.Join(datas, "\n") != strings.Join(tt.expectedDatas, "\n") {
+				t.Fatalf("messages got %v, want %v", datas, tt.expectedDatas)
+			}
+		})
+	}
+}
+
+func TestStreamableHTTPServerSession(t *testing.T) {
+	svr, httpSvr := newTestStreamableHTTPServer(t)
+
+	resp := doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("missing session: status code got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "unknown", "", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown session: status code got %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp = doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "application/json, text/event-stream", testInitializeRequest)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+
+	resp = doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, sessionID, "", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("notification: status code got %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	// server-initiated messages go to the GET stream
+	resp = doStreamableHTTPRequest(t, http.MethodGet, httpSvr.URL, sessionID, "text/event-stream", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get stream: status code got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	msg := `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`
+	go func() {
+		if err := svr.Send(context.Background(), sessionID, Message(msg)); err != nil {
+			t.Errorf("send failed: %v", err)
+		}
+	}()
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("read stream failed: %v", err)
+		}
+		if data := bytes.TrimPrefix(bytes.TrimSpace(line), []byte("data: ")); len(data) != len(bytes.TrimSpace(line)) {
+			if string(data) != msg {
+				t.Fatalf("get stream message got %s, want %s", data, msg)
+			}
+			break
+		}
+	}
+
+	resp = doStreamableHTTPRequest(t, http.MethodDelete, httpSvr.URL, sessionID, "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete: status code got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	resp = doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, sessionID, "", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted session: status code got %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestStreamableHTTPServerBatch(t *testing.T) {
+	svr, httpSvr := newTestStreamableHTTPServer(t)
+
+	resp := doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "application/json, text/event-stream", testInitializeRequest)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+
+	// answer the requests of a batch together
+	expected := `[{"jsonrpc":"2.0","id":3,"result":{}},{"jsonrpc":"2.0","id":2,"result":{}}]`
+	svr.SetReceiver(ServerReceiverF(func(_ context.Context, sessionID string, msg []byte) error {
+		if !gjson.ParseBytes(msg).IsArray() || len(requestIDsOf(msg)) == 0 {
+			return nil
+		}
+		go func() {
+			if err := svr.Send(context.Background(), sessionID, Message(expected)); err != nil {
+				t.Errorf("send response: %v", err)
+			}
+		}()
+		return nil
+	}))
+
+	resp = doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, sessionID, "application/json, text/event-stream",
+		`[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":2,"method":"ping"},{"jsonrpc":"2.0","id":3,"method":"ping"}]`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch: status code got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var datas []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+			datas = append(datas, data)
+		}
+	}
+	if len(datas) != 1 || datas[0] != expected {
+		t.Fatalf("batch messages got %v, want %s", datas, expected)
+	}
+
+	resp = doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, sessionID, "",
+		`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("batch of notifications: status code got %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+}
+
+func TestStreamableHTTPServerProtocolVersion(t *testing.T) {
+	_, httpSvr := newTestStreamableHTTPServer(t)
+
+	tests := []struct {
+		name         string
+		version      string
+		expectedCode int
+	}{
+		{name: "test_supported_version", version: "2024-11-05", expectedCode: http.StatusAccepted},
+		{name: "test_missing_version", expectedCode: http.StatusAccepted},
+		{name: "test_unsupported_version", version: "2000-01-01", expectedCode: http.StatusBadRequest},
+	}
+
+	resp := doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "application/json, text/event-stream", testInitializeRequest)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, httpSvr.URL, strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+			if err != nil {
+				t.Fatalf("NewRequest failed: %v", err)
+			}
+			req.Header.Set(sessionIDHeader, sessionID)
+			if tt.version != "" {
+				req.Header.Set(protocolVersionHeader, tt.version)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request failed: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedCode {
+				t.Fatalf("status code got %d, want %d", resp.StatusCode, tt.expectedCode)
+			}
+		})
+	}
+}
+
+func TestStreamableHTTPServerConfiguredVersions(t *testing.T) {
+	svr, httpSvr := newTestStreamableHTTPServer(t)
+	svr.(SupportedVersionsSetter).SetSupportedVersions([]string{"2025-03-26"})
+
+	resp := doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "application/json, text/event-stream", testInitializeRequest)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+
+	// 2024-11-05 is supported by the SDK but not by the server
+	req, err := http.NewRequest(http.MethodPost, httpSvr.URL, strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	req.Header.Set(sessionIDHeader, sessionID)
+	req.Header.Set(protocolVersionHeader, "2024-11-05")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status code got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestStreamableHTTPServerOrigin(t *testing.T) {
+	_, httpSvr := newTestStreamableHTTPServer(t, WithStreamableHTTPServerTransportAndHandlerOptionAllowedOrigins("https://example.com"))
+
+	tests := []struct {
+		name         string
+		origin       string
+		expectedCode int
+	}{
+		{name: "test_allowed_origin", origin: "https://example.com", expectedCode: http.StatusOK},
+		{name: "test_missing_origin", expectedCode: http.StatusOK},
+		{name: "test_disallowed_origin", origin: "https://attacker.example", expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, httpSvr.URL, strings.NewReader(testInitializeRequest))
+			if err != nil {
+				t.Fatalf("NewRequest failed: %v", err)
+			}
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request failed: %v", err)
+			}
+			_, _ = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedCode {
+				t.Fatalf("status code got %d, want %d", resp.StatusCode, tt.expectedCode)
+			}
+		})
+	}
+}
+
+// newTestStreamableHTTPSession initializes a session, then the server only signals the ids of the requests it receives
+// to received, leaving them unanswered
+func newTestStreamableHTTPSession(t *testing.T, opts ...StreamableHTTPServerTransportAndHandlerOption) (ServerTransport, string, string, chan string) {
+	svr, httpSvr := newTestStreamableHTTPServer(t, opts...)
+
+	received := make(chan string, 10)
+	svr.SetReceiver(ServerReceiverF(func(_ context.Context, sessionID string, msg []byte) error {
+		id := gjson.GetBytes(msg, "id")
+		if !id.Exists() {
+			return nil
+		}
+		if gjson.GetBytes(msg, "method").String() != "initialize" {
+			received <- id.Raw
+			return nil
+		}
+		go func() {
+			if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":`+id.Raw+`,"result":{}}`)); err != nil {
+				t.Errorf("send response: %v", err)
+			}
+		}()
+		return nil
+	}))
+
+	resp := doStreamableHTTPRequest(t, http.MethodPost, httpSvr.URL, "", "application/json, text/event-stream", testInitializeRequest)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+	return svr, httpSvr.URL, sessionID, received
+}
+
+// readStreamableHTTPResponse reads the messages of the response until it ends, failing the test after a second
+func readStreamableHTTPResponse(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+
+	timer := time.AfterFunc(time.Second, func() { _ = resp.Body.Close() })
+	defer timer.Stop()
+
+	var datas []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data := strings.TrimPrefix(scanner.Text(), "data: "); data != scanner.Text() {
+			datas = append(datas, data)
+		}
+	}
+	if scanner.Err() != nil {
+		t.Fatalf("response not ended: %v", scanner.Err())
+	}
+	return datas
+}
+
+func TestStreamableHTTPServerRelatedNotification(t *testing.T) {
+	svr, url, sessionID, received := newTestStreamableHTTPSession(t)
+
+	resps := make(map[string]chan *http.Response)
+	for _, id := range []string{"2", "3"} {
+		respCh := make(chan *http.Response, 1)
+		resps[id] = respCh
+		go func(id string) {
+			respCh <- doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "application/json, text/event-stream",
+				`{"jsonrpc":"2.0","id":`+id+`,"method":"ping"}`)
+		}(id)
+	}
+	<-received
+	<-received
+
+	// the notification goes to the stream of the request it is related to
+	notification := `{"jsonrpc":"2.0","method":"notifications/progress"}`
+	if err := svr.Send(WithRelatedRequestID(context.Background(), 3), sessionID, Message(notification)); err != nil {
+		t.Fatalf("send notification: %v", err)
+	}
+	expected := map[string][]string{
+		"2": {`{"jsonrpc":"2.0","id":2,"result":{}}`},
+		"3": {notification, `{"jsonrpc":"2.0","id":3,"result":{}}`},
+	}
+	for _, id := range []string{"2", "3"} {
+		if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":`+id+`,"result":{}}`)); err != nil {
+			t.Fatalf("send response: %v", err)
+		}
+		datas := readStreamableHTTPResponse(t, <-resps[id])
+		if strings.Join(datas, "\n") != strings.Join(expected[id], "\n") {
+			t.Fatalf("request %s: messages got %v, want %v", id, datas, expected[id])
+		}
+	}
+
+	// a notification unrelated to any request has no stream while the GET stream is not open
+	if err := svr.Send(context.Background(), sessionID, Message(notification)); !errors.Is(err, pkg.ErrLackStream) {
+		t.Fatalf("send unrelated notification: got %v, want %v", err, pkg.ErrLackStream)
+	}
+}
+
+func TestStreamableHTTPServerResponseRouting(t *testing.T) {
+	svr, url, sessionID, received := newTestStreamableHTTPSession(t, WithStreamableHTTPServerTransportAndHandlerOptionJSONResponse(true))
+
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		respCh <- doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "application/json", `{"jsonrpc":"2.0","id":"2","method":"ping"}`)
+	}()
+	<-received
+
+	// the numeric id 2 does not answer the request with the string id "2"
+	if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":2,"result":{}}`)); !errors.Is(err, pkg.ErrLackStream) {
+		t.Fatalf("send response of another id: got %v, want %v", err, pkg.ErrLackStream)
+	}
+	if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":"2","result":{}}`)); err != nil {
+		t.Fatalf("send response: %v", err)
+	}
+	if resp := <-respCh; resp.StatusCode != http.StatusOK {
+		t.Fatalf("string id: status code got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// the ids 3.0 and 3 are the same number
+	go func() {
+		respCh <- doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "application/json", `{"jsonrpc":"2.0","id":3.0,"method":"ping"}`)
+	}()
+	<-received
+	if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":3,"result":{}}`)); err != nil {
+		t.Fatalf("send response: %v", err)
+	}
+	if resp := <-respCh; resp.StatusCode != http.StatusOK {
+		t.Fatalf("numeric id: status code got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestStreamableHTTPServerCancelledRequest(t *testing.T) {
+	_, url, sessionID, received := newTestStreamableHTTPSession(t, WithStreamableHTTPServerTransportAndHandlerOptionJSONResponse(true))
+
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		respCh <- doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "application/json", `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	}()
+	<-received
+
+	// the cancelled request is never answered, its POST ends without a response
+	resp := doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "",
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2}}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("cancel: status code got %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	select {
+	case resp = <-respCh:
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("cancelled request: status code got %d, want %d", resp.StatusCode, http.StatusAccepted)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("cancelled request not ended")
+	}
+}
+
+func TestStreamableHTTPServerDeleteClosesStreams(t *testing.T) {
+	_, url, sessionID, received := newTestStreamableHTTPSession(t)
+
+	getResp := doStreamableHTTPRequest(t, http.MethodGet, url, sessionID, "text/event-stream", "")
+	if getResp.StatusCode != http.StatusOK {
+		t.Fatalf("get stream: status code got %d, want %d", getResp.StatusCode, http.StatusOK)
+	}
+	postResp := make(chan *http.Response, 1)
+	go func() {
+		postResp <- doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "application/json, text/event-stream",
+			`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	}()
+	<-received
+
+	resp := doStreamableHTTPRequest(t, http.MethodDelete, url, sessionID, "", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete: status code got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if datas := readStreamableHTTPResponse(t, getResp); len(datas) != 0 {
+		t.Fatalf("get stream messages got %v, want none", datas)
+	}
+	if datas := readStreamableHTTPResponse(t, <-postResp); len(datas) != 0 {
+		t.Fatalf("post stream messages got %v, want none", datas)
+	}
+}
+
+func TestStreamableHTTPServerSessionClosedEndsStreams(t *testing.T) {
+	svr, url, sessionID, received := newTestStreamableHTTPSession(t)
+	manager := &closeNotifyingSessionManager{mockSessionManager: svr.(*streamableHTTPServerTransport).sessionManager.(*mockSessionManager)}
+	svr.SetSessionManager(manager)
+
+	postResp := make(chan *http.Response, 1)
+	go func() {
+		postResp <- doStreamableHTTPRequest(t, http.MethodPost, url, sessionID, "application/json, text/event-stream",
+			`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	}()
+	<-received
+
+	// the session is closed by the session manager, such as by its heartbeat, not by a DELETE
+	manager.CloseSession(sessionID)
+
+	if datas := readStreamableHTTPResponse(t, <-postResp); len(datas) != 0 {
+		t.Fatalf("post stream messages got %v, want none", datas)
+	}
+}
+
+func TestStreamableHTTPServerSendAfterShutdown(t *testing.T) {
+	svr, _, sessionID, _ := newTestStreamableHTTPSession(t)
+
+	serverCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := svr.Shutdown(context.Background(), serverCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if err := svr.Send(context.Background(), sessionID, Message(`{"jsonrpc":"2.0","id":2,"result":{}}`)); err == nil {
+		t.Fatalf("send after shutdown: got nil error")
+	}
+}
@@ -2,8 +2,10 @@ package transport
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 )
 
 /*
@@ -32,6 +34,78 @@ type ClientTransport interface {
 	Close() error
 }
 
+// ConnectionState is the state of the connection of a client transport
+type ConnectionState int
+
+const (
+	// ConnectionStateConnected is reported once the connection is established by Start
+	ConnectionStateConnected ConnectionState = iota
+	// ConnectionStateReconnecting is reported when the connection drops, before reconnecting
+	ConnectionStateReconnecting
+	// ConnectionStateReconnected is reported when the reconnection resumed the same session
+	ConnectionStateReconnected
+	// ConnectionStateSessionLost is reported when the reconnection got a new session, which must be initialized again
+	ConnectionStateSessionLost
+	// ConnectionStateDisconnected is reported when the connection is lost for good, such as when the transport gave up reconnecting
+	ConnectionStateDisconnected
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionStateConnected:
+		return "connected"
+	case ConnectionStateReconnecting:
+		return "reconnecting"
+	case ConnectionStateReconnected:
+		return "reconnected"
+	case ConnectionStateSessionLost:
+		return "session lost"
+	case ConnectionStateDisconnected:
+		return "disconnected"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int(s))
+	}
+}
+
+// ConnectionStateNotifier is implemented by the client transports that report the changes of their connection,
+// such as those reconnecting by themselves
+type ConnectionStateNotifier interface {
+	// AddConnectionStateHandler registers handler to be called on each connection state change
+	AddConnectionStateHandler(handler func(state ConnectionState))
+}
+
+// ConnectionErrorReporter is implemented by the client transports that can tell why the connection was lost
+type ConnectionErrorReporter interface {
+	// ConnectionError returns the cause of the ConnectionStateDisconnected reported, nil while connected
+	ConnectionError() error
+}
+
+// ProtocolVersionSetter is implemented by the client transports that send the negotiated protocol version with their requests
+type ProtocolVersionSetter interface {
+	// SetProtocolVersion is called with the version negotiated by the initialization, before the following messages are sent
+	SetProtocolVersion(version string)
+}
+
+// SupportedVersionsSetter is implemented by the server transports that reject the requests of unsupported protocol versions
+type SupportedVersionsSetter interface {
+	// SetSupportedVersions is called with the protocol versions the server accepts, before it runs
+	SetSupportedVersions(versions []string)
+}
+
+type relatedRequestIDKey struct{}
+
+// WithRelatedRequestID marks the messages sent with ctx as related to the request of the peer with requestID,
+// so that the transports with a stream per request send them on the stream of that request
+func WithRelatedRequestID(ctx context.Context, requestID protocol.RequestID) context.Context {
+	return context.WithValue(ctx, relatedRequestIDKey{}, protocol.RequestIDKey(requestID))
+}
+
+// getRelatedRequestID returns the key, as made by protocol.RequestIDKey, of the request the messages sent with ctx are related to
+func getRelatedRequestID(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(relatedRequestIDKey{}).(string)
+	return requestID, ok
+}
+
 type clientReceiver interface {
 	Receive(ctx context.Context, msg []byte) error
 }
@@ -78,8 +152,15 @@ func (f ServerReceiverF) Receive(ctx context.Context, sessionID string, msg []by
 
 type sessionManager interface {
 	CreateSession(sessionID string)
+	IsExistSession(sessionID string) bool
 	SendMessage(ctx context.Context, sessionID string, message []byte) error
 	GetMessageForSend(ctx context.Context, sessionID string) ([]byte, error)
 	CloseSession(sessionID string)
 	CloseAllSessions()
 }
+
+// sessionCloseNotifier is implemented by the session managers that tell which sessions they close,
+// including those closed on their own, such as by the heartbeat
+type sessionCloseNotifier interface {
+	AddSessionCloseHandler(handler func(sessionID string))
+}
@@ -0,0 +1,394 @@
+package transport
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// The WebSocket protocol (RFC 6455) as far as MCP needs it: one JSON-RPC message per text message,
+// control frames answered as they are read, and the close handshake.
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+const (
+	wsCloseNormal          = 1000
+	wsCloseGoingAway       = 1001
+	wsCloseProtocolError   = 1002
+	wsCloseUnsupportedData = 1003
+	wsCloseNoStatus        = 1005
+	wsCloseInvalidPayload  = 1007
+	wsCloseMessageTooBig   = 1009
+)
+
+const (
+	wsGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsProtocol = "mcp"
+
+	wsMaxMessageSize      = 32 << 20
+	wsWriteTimeout        = 10 * time.Second
+	wsCloseTimeout        = 5 * time.Second
+	defaultWSPingInterval = 30 * time.Second
+)
+
+var errWSClosed = errors.New("websocket connection closed")
+
+// wsCloseError is returned by readMessage when the connection is closed by a close frame
+type wsCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket closed: code=%d reason=%s", e.code, e.reason)
+}
+
+// isNormalWSClose reports whether err is the expected end of a connection
+func isNormalWSClose(err error) bool {
+	var closeErr *wsCloseError
+	if errors.As(err, &closeErr) {
+		return closeErr.code == wsCloseNormal || closeErr.code == wsCloseGoingAway || closeErr.code == wsCloseNoStatus
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
+
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	// the frames a client sends are masked, the ones a server sends are not
+	client bool
+
+	// a message must be read within readTimeout, the peer answering the pings sent meanwhile, 0 means no timeout
+	readTimeout time.Duration
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+func (c *wsConn) readMessage() ([]byte, error) {
+	var (
+		message []byte
+		started bool
+	)
+
+	for {
+		if c.readTimeout > 0 && !c.isCloseSent() {
+			if err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout)); err != nil {
+				return nil, err
+			}
+		}
+
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err = c.writeFrame(wsOpPong, payload); err != nil && !errors.Is(err, errWSClosed) {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			closeErr := &wsCloseError{code: wsCloseNoStatus}
+			if len(payload) == 1 {
+				return nil, c.failf(wsCloseProtocolError, "invalid close frame")
+			}
+			if len(payload) >= 2 {
+				closeErr.code = int(binary.BigEndian.Uint16(payload))
+				closeErr.reason = string(payload[2:])
+				if !isValidWSCloseCode(closeErr.code) {
+					return nil, c.failf(wsCloseProtocolError, fmt.Sprintf("invalid close code %d", closeErr.code))
+				}
+				if !utf8.ValidString(closeErr.reason) {
+					return nil, c.failf(wsCloseInvalidPayload, "invalid UTF-8 in close reason")
+				}
+			}
+			// echo the close frame to complete the close handshake
+			replyCode := closeErr.code
+			if replyCode == wsCloseNoStatus {
+				replyCode = wsCloseNormal
+			}
+			_ = c.writeClose(replyCode, "")
+			return nil, closeErr
+		case wsOpText:
+			if started {
+				return nil, c.failf(wsCloseProtocolError, "new message before the end of the fragmented one")
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				return nil, c.failf(wsCloseProtocolError, "continuation frame without a message")
+			}
+		case wsOpBinary:
+			return nil, c.failf(wsCloseUnsupportedData, "binary messages are not supported")
+		default:
+			return nil, c.failf(wsCloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		if len(message)+len(payload) > wsMaxMessageSize {
+			return nil, c.failf(wsCloseMessageTooBig, "message too big")
+		}
+		message = append(message, payload...)
+
+		if fin {
+			if !utf8.Valid(message) {
+				return nil, c.failf(wsCloseInvalidPayload, "invalid UTF-8 in text message")
+			}
+			return message, nil
+		}
+	}
+}
+
+// isValidWSCloseCode reports whether code may be sent in a close frame, the codes reserved for reporting
+// a connection closed without a close frame, such as 1005 and 1006, must not
+func isValidWSCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.failf(wsCloseProtocolError, "reserved bits set")
+	}
+
+	masked := header[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, c.failf(wsCloseProtocolError, "invalid frame masking")
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= wsOpClose && (!fin || length > 125) {
+		return false, 0, nil, c.failf(wsCloseProtocolError, "invalid control frame")
+	}
+	if length > wsMaxMessageSize {
+		return false, 0, nil, c.failf(wsCloseMessageTooBig, "message too big")
+	}
+
+	var maskKey [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, maskKey[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(maskKey, payload)
+	}
+	return fin, opcode, payload, nil
+}
+
+// failf closes the connection with code because of a peer misbehaving
+func (c *wsConn) failf(code int, reason string) error {
+	_ = c.writeClose(code, reason)
+	return &wsCloseError{code: code, reason: reason}
+}
+
+func (c *wsConn) writeMessage(msg []byte) error {
+	return c.writeFrame(wsOpText, msg)
+}
+
+func (c *wsConn) ping() error {
+	return c.writeFrame(wsOpPing, nil)
+}
+
+// writeClose starts the close handshake, the peer is then given wsCloseTimeout to answer it
+func (c *wsConn) writeClose(code int, reason string) error {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+
+	if err := c.writeFrame(wsOpClose, payload); err != nil {
+		return err
+	}
+	return c.conn.SetReadDeadline(time.Now().Add(wsCloseTimeout))
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return errWSClosed
+	}
+	if opcode == wsOpClose {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|opcode)
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[len(frame)-2:], uint16(length))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[len(frame)-8:], uint64(length))
+	}
+
+	if c.client {
+		var maskKey [4]byte
+		if _, err := rand.Read(maskKey[:]); err != nil {
+			return fmt.Errorf("failed to generate mask key: %w", err)
+		}
+		frame = append(frame, maskKey[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(maskKey, frame[start:])
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *wsConn) isCloseSent() bool {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.closeSent
+}
+
+func (c *wsConn) close() error {
+	return c.conn.Close()
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
+
+func wsAcceptKey(key string) string {
+	h := sha1.New() //nolint:gosec
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket completes the opening handshake of a server, the error response is already written if it fails.
+// Browsers do not apply the same-origin policy to WebSocket, so the Origin is checked against allowedOrigins.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("unexpected method %s", r.Method)
+	}
+	if !isOriginAllowed(r, allowedOrigins) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("origin %q not allowed", r.Header.Get("Origin"))
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "WebSocket upgrade required", http.StatusBadRequest)
+		return nil, errors.New("not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("unsupported websocket version %q", r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response writer does not support hijacking")
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("failed to hijack connection: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n"
+	if headerContainsToken(r.Header, "Sec-WebSocket-Protocol", wsProtocol) {
+		response += "Sec-WebSocket-Protocol: " + wsProtocol + "\r\n"
+	}
+	if _, err = brw.WriteString(response + "\r\n"); err == nil {
+		err = brw.Flush()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to write handshake response: %w", err)
+	}
+
+	// a deadline set by the HTTP server must not apply to the websocket
+	_ = conn.SetDeadline(time.Time{})
+
+	return &wsConn{conn: conn, br: brw.Reader}, nil
+}
@@ -0,0 +1,340 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+type WebSocketClientTransportOption func(*webSocketClientTransport)
+
+func WithWebSocketClientOptionReceiveTimeout(timeout time.Duration) WebSocketClientTransportOption {
+	return func(t *webSocketClientTransport) {
+		t.receiveTimeout = timeout
+	}
+}
+
+func WithWebSocketClientOptionLogger(log pkg.Logger) WebSocketClientTransportOption {
+	return func(t *webSocketClientTransport) {
+		t.logger = log
+	}
+}
+
+// WithWebSocketClientOptionHeader sets the headers sent with the opening handshake, such as Authorization
+func WithWebSocketClientOptionHeader(header http.Header) WebSocketClientTransportOption {
+	return func(t *webSocketClientTransport) {
+		t.header = header
+	}
+}
+
+// WithWebSocketClientOptionTLSConfig sets the TLS configuration used for wss URLs
+func WithWebSocketClientOptionTLSConfig(config *tls.Config) WebSocketClientTransportOption {
+	return func(t *webSocketClientTransport) {
+		t.tlsConfig = config
+	}
+}
+
+// WithWebSocketClientOptionPingInterval sets the interval of the pings keeping the connection alive,
+// the connection is considered lost when silent for twice the interval, 0 disables the pings.
+func WithWebSocketClientOptionPingInterval(interval time.Duration) WebSocketClientTransportOption {
+	return func(t *webSocketClientTransport) {
+		t.pingInterval = interval
+	}
+}
+
+type webSocketClientTransport struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	serverURL *url.URL
+
+	conn     *wsConn
+	receiver clientReceiver
+
+	receiveShutDone chan struct{}
+
+	stateMu       sync.Mutex
+	stateHandlers []func(state ConnectionState)
+	// connErr is the cause of the loss of the connection, set before ConnectionStateDisconnected is reported
+	connErr error
+
+	// options
+	logger         pkg.Logger
+	receiveTimeout time.Duration
+	header         http.Header
+	tlsConfig      *tls.Config
+	pingInterval   time.Duration
+}
+
+// NewWebSocketClientTransport returns transport connecting to serverURL, a ws or wss URL
+func NewWebSocketClientTransport(serverURL string, opts ...WebSocketClientTransportOption) (ClientTransport, error) {
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server URL: %w", err)
+	}
+	switch parsedURL.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported websocket URL scheme: %s", parsedURL.Scheme)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &webSocketClientTransport{
+		ctx:             ctx,
+		cancel:          cancel,
+		serverURL:       parsedURL,
+		receiveShutDone: make(chan struct{}),
+		logger:          pkg.DefaultLogger,
+		receiveTimeout:  time.Second * 30,
+		pingInterval:    defaultWSPingInterval,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t, nil
+}
+
+func (t *webSocketClientTransport) Start() error {
+	ctx, cancel := context.WithTimeout(t.ctx, 10*time.Second)
+	defer cancel()
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+	if t.pingInterval > 0 {
+		conn.readTimeout = 2 * t.pingInterval
+	}
+	t.conn = conn
+
+	go func() {
+		defer pkg.Recover()
+		defer close(t.receiveShutDone)
+
+		t.receive()
+	}()
+
+	if t.pingInterval > 0 {
+		go func() {
+			defer pkg.Recover()
+
+			t.keepAlive()
+		}()
+	}
+
+	t.notifyState(ConnectionStateConnected)
+	return nil
+}
+
+// dial opens the connection and completes the opening handshake
+func (t *webSocketClientTransport) dial(ctx context.Context) (*wsConn, error) {
+	handshakeURL := *t.serverURL
+	secure := handshakeURL.Scheme == "wss" || handshakeURL.Scheme == "https"
+	if secure {
+		handshakeURL.Scheme = "https"
+	} else {
+		handshakeURL.Scheme = "http"
+	}
+
+	addr := handshakeURL.Host
+	if handshakeURL.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(handshakeURL.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(handshakeURL.Hostname(), "80")
+		}
+	}
+
+	var (
+		netConn net.Conn
+		err     error
+	)
+	if secure {
+		netConn, err = (&tls.Dialer{Config: t.tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		netConn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := t.handshake(ctx, netConn, &handshakeURL)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (t *webSocketClientTransport) handshake(ctx context.Context, netConn net.Conn, handshakeURL *url.URL) (*wsConn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := netConn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, handshakeURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", wsProtocol)
+
+	if err = req.Write(netConn); err != nil {
+		return nil, fmt.Errorf("failed to send handshake: %w", err)
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read handshake response: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("unexpected status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		return nil, fmt.Errorf("invalid Sec-WebSocket-Accept")
+	}
+
+	if err = netConn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return &wsConn{conn: netConn, br: br, client: true}, nil
+}
+
+// receive reads the messages of the connection until it is closed,
+// reporting the loss of the connection unless the transport is being closed.
+func (t *webSocketClientTransport) receive() {
+	defer func() {
+		_ = t.conn.close()
+	}()
+
+	for {
+		msg, err := t.conn.readMessage()
+		if err != nil {
+			if t.ctx.Err() != nil {
+				return
+			}
+			if !isNormalWSClose(err) {
+				t.logger.Errorf("websocket connection lost: %v", err)
+			}
+
+			t.stateMu.Lock()
+			t.connErr = fmt.Errorf("%w: %v", pkg.ErrConnectionLost, err)
+			t.stateMu.Unlock()
+			t.notifyState(ConnectionStateDisconnected)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(t.ctx, t.receiveTimeout)
+		if err = t.receiver.Receive(ctx, msg); err != nil {
+			t.logger.Errorf("Error receive message: %v", err)
+		}
+		cancel()
+	}
+}
+
+func (t *webSocketClientTransport) keepAlive() {
+	ticker := time.NewTicker(t.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-t.receiveShutDone:
+			return
+		case <-ticker.C:
+			if err := t.conn.ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t *webSocketClientTransport) Send(ctx context.Context, msg Message) error {
+	t.logger.Debugf("Sending message: %s to %s", msg, t.serverURL.String())
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := t.conn.writeMessage(msg); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+func (t *webSocketClientTransport) SetReceiver(receiver clientReceiver) {
+	t.receiver = receiver
+}
+
+func (t *webSocketClientTransport) AddConnectionStateHandler(handler func(state ConnectionState)) {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+
+	t.stateHandlers = append(t.stateHandlers, handler)
+}
+
+// ConnectionError returns the cause of the loss of the connection, matching pkg.ErrConnectionLost with errors.Is
+func (t *webSocketClientTransport) ConnectionError() error {
+	t.stateMu.Lock()
+	defer t.stateMu.Unlock()
+
+	return t.connErr
+}
+
+func (t *webSocketClientTransport) notifyState(state ConnectionState) {
+	t.logger.Debugf("websocket connection state: %s", state)
+
+	t.stateMu.Lock()
+	handlers := append([]func(ConnectionState){}, t.stateHandlers...)
+	t.stateMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(state)
+	}
+}
+
+// Close starts the close handshake and waits for the server to answer it
+func (t *webSocketClientTransport) Close() error {
+	t.cancel()
+
+	if t.conn == nil {
+		return nil
+	}
+
+	if err := t.conn.writeClose(wsCloseNormal, ""); err != nil {
+		_ = t.conn.close()
+	}
+
+	<-t.receiveShutDone
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+type WebSocketServerTransportOption func(*webSocketServerTransport)
+
+func WithWebSocketServerTransportOptionLogger(logger pkg.Logger) WebSocketServerTransportOption {
+	return func(t *webSocketServerTransport) {
+		t.logger = logger
+	}
+}
+
+func WithWebSocketServerTransportOptionPath(path string) WebSocketServerTransportOption {
+	return func(t *webSocketServerTransport) {
+		t.path = path
+	}
+}
+
+// WithWebSocketServerTransportOptionPingInterval sets the interval of the pings keeping the connections alive,
+// a connection silent for twice the interval is closed, 0 disables the pings.
+func WithWebSocketServerTransportOptionPingInterval(interval time.Duration) WebSocketServerTransportOption {
+	return func(t *webSocketServerTransport) {
+		t.pingInterval = interval
+	}
+}
+
+// WithWebSocketServerTransportOptionAllowedOrigins refuses with 403 the upgrade requests whose Origin is not one of origins,
+// "*" allowing any. Every origin is allowed by default.
+func WithWebSocketServerTransportOptionAllowedOrigins(origins ...string) WebSocketServerTransportOption {
+	return func(t *webSocketServerTransport) {
+		t.allowedOrigins = origins
+	}
+}
+
+type WebSocketServerTransportAndHandlerOption func(*webSocketServerTransport)
+
+func WithWebSocketServerTransportAndHandlerOptionLogger(logger pkg.Logger) WebSocketServerTransportAndHandlerOption {
+	return func(t *webSocketServerTransport) {
+		t.logger = logger
+	}
+}
+
+func WithWebSocketServerTransportAndHandlerOptionPingInterval(interval time.Duration) WebSocketServerTransportAndHandlerOption {
+	return func(t *webSocketServerTransport) {
+		t.pingInterval = interval
+	}
+}
+
+func WithWebSocketServerTransportAndHandlerOptionAllowedOrigins(origins ...string) WebSocketServerTransportAndHandlerOption {
+	return func(t *webSocketServerTransport) {
+		t.allowedOrigins = origins
+	}
+}
+
+type webSocketServerTransport struct {
+	// ctx is the context that controls the lifecycle of the server.
+	// It is used to coordinate cancellation of all ongoing send operations when the server is shutting down.
+	ctx context.Context
+	// cancel is the function to cancel the ctx when the server needs to shut down.
+	cancel context.CancelFunc
+
+	httpSvr *http.Server
+
+	inFlySend sync.WaitGroup
+
+	receiver serverReceiver
+
+	sessionManager sessionManager
+
+	// options
+	logger         pkg.Logger
+	path           string
+	pingInterval   time.Duration
+	allowedOrigins []string
+}
+
+type WebSocketHandler struct {
+	transport *webSocketServerTransport
+}
+
+// HandleWebSocket upgrades the requests of clients to WebSocket connections, each connection being a session.
+func (h *WebSocketHandler) HandleWebSocket() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.transport.handleWebSocket(w, r)
+	})
+}
+
+// NewWebSocketServerTransport returns transport that will start an HTTP server
+func NewWebSocketServerTransport(addr string, opts ...WebSocketServerTransportOption) (ServerTransport, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &webSocketServerTransport{
+		ctx:          ctx,
+		cancel:       cancel,
+		logger:       pkg.DefaultLogger,
+		path:         "/ws",
+		pingInterval: defaultWSPingInterval,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(t.path, t.handleWebSocket)
+
+	t.httpSvr = &http.Server{
+		Addr:        addr,
+		Handler:     mux,
+		IdleTimeout: time.Minute,
+	}
+
+	return t, nil
+}
+
+// NewWebSocketServerTransportAndHandler returns transport without starting the HTTP server,
+// and returns a Handler for users to start their own HTTP server externally
+// eg:
+// transport, handler, _ := NewWebSocketServerTransportAndHandler()
+// http.Handle("/ws", handler.HandleWebSocket())
+// http.ListenAndServe(":8080", nil)
+func NewWebSocketServerTransportAndHandler(
+	opts ...WebSocketServerTransportAndHandlerOption,
+) (ServerTransport, *WebSocketHandler, error) { //nolint:whitespace
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &webSocketServerTransport{
+		ctx:          ctx,
+		cancel:       cancel,
+		logger:       pkg.DefaultLogger,
+		pingInterval: defaultWSPingInterval,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t, &WebSocketHandler{transport: t}, nil
+}
+
+func (t *webSocketServerTransport) Run() error {
+	if t.httpSvr == nil {
+		<-t.ctx.Done()
+		return nil
+	}
+
+	if err := t.httpSvr.ListenAndServe(); err != nil {
+		return fmt.Errorf("failed to start HTTP server: %w", err)
+	}
+	return nil
+}
+
+func (t *webSocketServerTransport) Send(ctx context.Context, sessionID string, msg Message) error {
+	t.inFlySend.Add(1)
+	defer t.inFlySend.Done()
+
+	select {
+	case <-t.ctx.Done():
+		return errors.New("websocket server transport already shutdown")
+	default:
+	}
+
+	return t.sessionManager.SendMessage(ctx, sessionID, msg)
+}
+
+func (t *webSocketServerTransport) SetReceiver(receiver serverReceiver) {
+	t.receiver = receiver
+}
+
+func (t *webSocketServerTransport) SetSessionManager(manager sessionManager) {
+	t.sessionManager = manager
+}
+
+// handleWebSocket reads the messages of a connection until it is closed,
+// while the messages sent to its session are written by another goroutine.
+func (t *webSocketServerTransport) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	defer pkg.Recover()
+
+	conn, err := upgradeWebSocket(w, r, t.allowedOrigins)
+	if err != nil {
+		t.logger.Debugf("websocket upgrade failed: %v", err)
+		return
+	}
+	if t.pingInterval > 0 {
+		conn.readTimeout = 2 * t.pingInterval
+	}
+
+	sessionID := uuid.New().String()
+	t.sessionManager.CreateSession(sessionID)
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	writeDone := make(chan struct{})
+	defer func() {
+		cancel()
+		<-writeDone
+		t.sessionManager.CloseSession(sessionID)
+		_ = conn.close()
+	}()
+
+	go func() {
+		defer pkg.Recover()
+		defer close(writeDone)
+
+		t.writeLoop(ctx, conn, sessionID)
+	}()
+
+	for {
+		msg, err := conn.readMessage()
+		if err != nil {
+			if !isNormalWSClose(err) && ctx.Err() == nil {
+				t.logger.Warnf("websocket read failed: %v, sessionID=%s", err, sessionID)
+			}
+			return
+		}
+
+		t.logger.Debugf("Received message: %s", string(msg))
+
+		if err = t.receiver.Receive(ctx, sessionID, msg); err != nil {
+			t.logger.Errorf("receiver failed: %v, sessionID=%s", err, sessionID)
+		}
+	}
+}
+
+// writeLoop writes the messages of the session and the keepalive pings,
+// then starts the close handshake when the session or the server is closed.
+func (t *webSocketServerTransport) writeLoop(ctx context.Context, conn *wsConn, sessionID string) {
+	msgCh := make(chan []byte)
+	errCh := make(chan error, 1)
+	go func() {
+		defer pkg.Recover()
+
+		for {
+			msg, err := t.sessionManager.GetMessageForSend(ctx, sessionID)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var ping <-chan time.Time
+	if t.pingInterval > 0 {
+		ticker := time.NewTicker(t.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case msg := <-msgCh:
+			t.logger.Debugf("Sending message: %s", string(msg))
+
+			if err := conn.writeMessage(msg); err != nil {
+				t.logger.Errorf("Failed to write message: %v", err)
+				_ = conn.close()
+				return
+			}
+		case <-ping:
+			if err := conn.ping(); err != nil {
+				_ = conn.close()
+				return
+			}
+		case err := <-errCh:
+			switch {
+			case t.ctx.Err() != nil:
+				_ = conn.writeClose(wsCloseGoingAway, "server shutdown")
+			case errors.Is(err, pkg.ErrSendEOF) || errors.Is(err, pkg.ErrLackSession):
+				_ = conn.writeClose(wsCloseNormal, "session closed")
+			}
+			return
+		case <-ctx.Done():
+			if t.ctx.Err() != nil {
+				_ = conn.writeClose(wsCloseGoingAway, "server shutdown")
+			}
+			return
+		}
+	}
+}
+
+func (t *webSocketServerTransport) Shutdown(userCtx context.Context, serverCtx context.Context) error {
+	shutdownFunc := func() {
+		<-serverCtx.Done()
+
+		t.cancel()
+
+		t.inFlySend.Wait()
+
+		t.sessionManager.CloseAllSessions()
+	}
+
+	if t.httpSvr == nil {
+		shutdownFunc()
+		return nil
+	}
+
+	t.httpSvr.RegisterOnShutdown(shutdownFunc)
+
+	if err := t.httpSvr.Shutdown(userCtx); err != nil {
+		return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,397 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+func TestWebSocket(t *testing.T) {
+	var (
+		err    error
+		svr    ServerTransport
+		client ClientTransport
+	)
+
+	// Get an available port
+	port, err := getAvailablePort()
+	if err != nil {
+		t.Fatalf("Failed to get available port: %v", err)
+	}
+
+	serverAddr := fmt.Sprintf("127.0.0.1:%d", port)
+	clientURL := fmt.Sprintf("ws://%s/ws", serverAddr)
+
+	if svr, err = NewWebSocketServerTransport(serverAddr); err != nil {
+		t.Fatalf("NewWebSocketServerTransport failed: %v", err)
+	}
+
+	if client, err = NewWebSocketClientTransport(clientURL); err != nil {
+		t.Fatalf("NewWebSocketClientTransport failed: %v", err)
+	}
+
+	testTransport(t, client, newTestSessionServerTransport(svr))
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	svr, handler, err := NewWebSocketServerTransportAndHandler(WithWebSocketServerTransportAndHandlerOptionPingInterval(20 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWebSocketServerTransportAndHandler failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", handler.HandleWebSocket())
+	httpSvr := httptest.NewServer(mux)
+	defer httpSvr.Close()
+
+	client, err := NewWebSocketClientTransport("ws"+strings.TrimPrefix(httpSvr.URL, "http")+"/ws",
+		WithWebSocketClientOptionPingInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWebSocketClientTransport failed: %v", err)
+	}
+
+	testTransport(t, client, newTestSessionServerTransport(svr))
+}
+
+// testSessionServerTransport sends the messages testTransport addresses to no session to the session of the client,
+// which is only known once its first message is received
+type testSessionServerTransport struct {
+	ServerTransport
+
+	sessionID chan string
+}
+
+func newTestSessionServerTransport(svr ServerTransport) ServerTransport {
+	return &testSessionServerTransport{ServerTransport: svr, sessionID: make(chan string, 1)}
+}
+
+func (t *testSessionServerTransport) SetReceiver(receiver serverReceiver) {
+	t.ServerTransport.SetReceiver(ServerReceiverF(func(ctx context.Context, sessionID string, msg []byte) error {
+		select {
+		case t.sessionID <- sessionID:
+		default:
+		}
+		return receiver.Receive(ctx, sessionID, msg)
+	}))
+}
+
+func (t *testSessionServerTransport) Send(ctx context.Context, sessionID string, msg Message) error {
+	if sessionID == "" {
+		select {
+		case sessionID = <-t.sessionID:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return t.ServerTransport.Send(ctx, sessionID, msg)
+}
+
+func TestWebSocketSendAfterShutdown(t *testing.T) {
+	svr, _, err := NewWebSocketServerTransportAndHandler()
+	if err != nil {
+		t.Fatalf("NewWebSocketServerTransportAndHandler failed: %v", err)
+	}
+	svr.SetSessionManager(newMockSessionManager())
+
+	serverCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = svr.Shutdown(context.Background(), serverCtx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if err = svr.Send(context.Background(), "session", Message(`{}`)); err == nil {
+		t.Fatalf("send after shutdown: got nil error")
+	}
+}
+
+func TestWebSocketSessionClose(t *testing.T) {
+	svr, handler, err := NewWebSocketServerTransportAndHandler(WithWebSocketServerTransportAndHandlerOptionPingInterval(20 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWebSocketServerTransportAndHandler failed: %v", err)
+	}
+	sessionManager := newMockSessionManager()
+	svr.SetSessionManager(sessionManager)
+
+	// a message over 64KiB is framed with the 64 bits payload length
+	large := `{"data":"` + strings.Repeat("x", 70000) + `"}`
+	sessionIDCh := make(chan string, 1)
+	svr.SetReceiver(ServerReceiverF(func(_ context.Context, sessionID string, msg []byte) error {
+		if string(msg) != large {
+			t.Errorf("received message of %d bytes, want %d", len(msg), len(large))
+		}
+		sessionIDCh <- sessionID
+		return nil
+	}))
+
+	httpSvr := httptest.NewServer(handler.HandleWebSocket())
+	defer httpSvr.Close()
+
+	client, err := NewWebSocketClientTransport("ws"+strings.TrimPrefix(httpSvr.URL, "http"),
+		WithWebSocketClientOptionPingInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWebSocketClientTransport failed: %v", err)
+	}
+	client.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+	if err = client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer client.Close()
+
+	if err = client.Send(context.Background(), Message(large)); err != nil {
+		t.Fatalf("client.Send() failed: %v", err)
+	}
+	sessionID := <-sessionIDCh
+
+	// the connection is kept alive by the pings while idle
+	time.Sleep(100 * time.Millisecond)
+	if !sessionManager.IsExistSession(sessionID) {
+		t.Fatalf("session closed while idle")
+	}
+
+	// closing the session closes the connection
+	sessionManager.CloseSession(sessionID)
+	select {
+	case <-client.(*webSocketClientTransport).receiveShutDone:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("connection not closed with the session")
+	}
+	if err = client.Send(context.Background(), Message("{}")); err == nil {
+		t.Fatalf("client.Send() on closed connection succeeded")
+	}
+}
+
+func TestWebSocketUpgradeRequired(t *testing.T) {
+	_, handler, err := NewWebSocketServerTransportAndHandler()
+	if err != nil {
+		t.Fatalf("NewWebSocketServerTransportAndHandler failed: %v", err)
+	}
+	httpSvr := httptest.NewServer(handler.HandleWebSocket())
+	defer httpSvr.Close()
+
+	resp, err := http.Get(httpSvr.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status code got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	_, handler, err := NewWebSocketServerTransportAndHandler(WithWebSocketServerTransportAndHandlerOptionAllowedOrigins("https://example.com"))
+	if err != nil {
+		t.Fatalf("NewWebSocketServerTransportAndHandler failed: %v", err)
+	}
+	httpSvr := httptest.NewServer(handler.HandleWebSocket())
+	defer httpSvr.Close()
+
+	req, err := http.NewRequest(http.MethodGet, httpSvr.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	req.Header.Set("Origin", "https://attacker.example")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status code got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+// wsTestFrame returns an unmasked frame, as sent by a server
+func wsTestFrame(fin bool, opcode byte, payload []byte, length uint64) []byte {
+	frame := []byte{opcode, 127, 0, 0, 0, 0, 0, 0, 0, 0}
+	if fin {
+		frame[0] |= 0x80
+	}
+	binary.BigEndian.PutUint64(frame[2:], length)
+	return append(frame, payload...)
+}
+
+func wsTestClosePayload(code int) []byte {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	return payload
+}
+
+func TestWebSocketReadMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		frames       [][]byte
+		expectedMsg  string
+		expectedCode int
+	}{
+		{
+			name: "test_fragmented_message",
+			frames: [][]byte{
+				wsTestFrame(false, wsOpText, []byte(`{"a":`), 5),
+				wsTestFrame(true, wsOpPing, nil, 0),
+				wsTestFrame(false, wsOpContinuation, []byte(`"b"`), 3),
+				wsTestFrame(true, wsOpContinuation, []byte(`}`), 1),
+			},
+			expectedMsg: `{"a":"b"}`,
+		},
+		{
+			name:         "test_oversize_frame",
+			frames:       [][]byte{wsTestFrame(true, wsOpText, nil, wsMaxMessageSize+1)},
+			expectedCode: wsCloseMessageTooBig,
+		},
+		{
+			name: "test_oversize_fragmented_message",
+			frames: [][]byte{
+				wsTestFrame(false, wsOpText, make([]byte, wsMaxMessageSize/2+1), wsMaxMessageSize/2+1),
+				wsTestFrame(true, wsOpContinuation, make([]byte, wsMaxMessageSize/2+1), wsMaxMessageSize/2+1),
+			},
+			expectedCode: wsCloseMessageTooBig,
+		},
+		{
+			name: "test_continuation_without_message",
+			frames: [][]byte{
+				wsTestFrame(true, wsOpContinuation, []byte(`{}`), 2),
+			},
+			expectedCode: wsCloseProtocolError,
+		},
+		{
+			name: "test_invalid_utf8",
+			frames: [][]byte{
+				wsTestFrame(false, wsOpText, []byte{'"', 0xe2, 0x82}, 3),
+				wsTestFrame(true, wsOpContinuation, []byte{0x28, '"'}, 2),
+			},
+			expectedCode: wsCloseInvalidPayload,
+		},
+		{
+			name:         "test_close",
+			frames:       [][]byte{wsTestFrame(true, wsOpClose, wsTestClosePayload(wsCloseNormal), 2)},
+			expectedCode: wsCloseNormal,
+		},
+		{
+			name:         "test_close_reserved_code",
+			frames:       [][]byte{wsTestFrame(true, wsOpClose, wsTestClosePayload(wsCloseNoStatus), 2)},
+			expectedCode: wsCloseProtocolError,
+		},
+		{
+			name:         "test_close_code_below_1000",
+			frames:       [][]byte{wsTestFrame(true, wsOpClose, wsTestClosePayload(999), 2)},
+			expectedCode: wsCloseProtocolError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConn, clientConn := net.Pipe()
+			defer serverConn.Close()
+			defer clientConn.Close()
+
+			go func() {
+				for _, frame := range tt.frames {
+					if _, err := serverConn.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+
+			// the frames written back by the client: the pong, then the close frame on failure
+			written := make(chan []byte, 2)
+			go func() {
+				peer := &wsConn{conn: serverConn, br: bufio.NewReader(serverConn)}
+				for {
+					_, opcode, payload, err := peer.readFrame()
+					if err != nil {
+						return
+					}
+					if opcode == wsOpClose {
+						written <- payload
+					}
+				}
+			}()
+
+			conn := &wsConn{conn: clientConn, br: bufio.NewReader(clientConn), client: true}
+			msg, err := conn.readMessage()
+			if tt.expectedCode == 0 {
+				if err != nil {
+					t.Fatalf("readMessage failed: %v", err)
+				}
+				if string(msg) != tt.expectedMsg {
+					t.Fatalf("message got %s, want %s", msg, tt.expectedMsg)
+				}
+				return
+			}
+
+			var closeErr *wsCloseError
+			if !errors.As(err, &closeErr) || closeErr.code != tt.expectedCode {
+				t.Fatalf("readMessage error got %v, want close code %d", err, tt.expectedCode)
+			}
+			select {
+			case payload := <-written:
+				if code := int(binary.BigEndian.Uint16(payload)); code != tt.expectedCode {
+					t.Fatalf("close frame code got %d, want %d", code, tt.expectedCode)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("close frame not sent")
+			}
+		})
+	}
+}
+
+func TestWebSocketPingTimeout(t *testing.T) {
+	// the server completes the handshake, then neither reads nor answers the pings
+	release := make(chan struct{})
+	httpSvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgradeWebSocket(w, r, nil)
+		if err != nil {
+			return
+		}
+		<-release
+		_ = conn.close()
+	}))
+	defer httpSvr.Close()
+	defer close(release)
+
+	client, err := NewWebSocketClientTransport("ws"+strings.TrimPrefix(httpSvr.URL, "http"),
+		WithWebSocketClientOptionPingInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWebSocketClientTransport failed: %v", err)
+	}
+	client.SetReceiver(ClientReceiverF(func(context.Context, []byte) error { return nil }))
+	states := make(chan ConnectionState, 2)
+	client.(ConnectionStateNotifier).AddConnectionStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+	if err = client.Start(); err != nil {
+		t.Fatalf("client.Start() failed: %v", err)
+	}
+	defer client.Close()
+
+	for _, want := range []ConnectionState{ConnectionStateConnected, ConnectionStateDisconnected} {
+		select {
+		case got := <-states:
+			if got != want {
+				t.Fatalf("connection state got %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("connection state %s not reported", want)
+		}
+	}
+	if err = client.(ConnectionErrorReporter).ConnectionError(); !errors.Is(err, pkg.ErrConnectionLost) {
+		t.Fatalf("connection error got %v, want %v", err, pkg.ErrConnectionLost)
+	}
+}